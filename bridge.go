@@ -13,35 +13,40 @@ type BBox struct {
 
 // WithinBBox detects if the object is fully contained inside a bbox.
 func (g Object) WithinBBox(bbox BBox) bool {
-	return g.bridge().WithinBBox(geojson.BBox{
-		Min: geojson.Position{bbox.Min.X, bbox.Min.Y, bbox.Min.Z},
-		Max: geojson.Position{bbox.Max.X, bbox.Max.Y, bbox.Max.Z},
-	})
+	v, ok := g.view()
+	return ok && v.withinBBox(bbox)
 }
 
 // IntersectsBBox detects if the object intersects a bbox.
 func (g Object) IntersectsBBox(bbox BBox) bool {
-	return g.bridge().IntersectsBBox(geojson.BBox{
-		Min: geojson.Position{bbox.Min.X, bbox.Min.Y, bbox.Min.Z},
-		Max: geojson.Position{bbox.Max.X, bbox.Max.Y, bbox.Max.Z},
-	})
+	v, ok := g.view()
+	return ok && v.intersectsBBox(bbox)
 }
 
 // Within detects if the object is fully contained inside another object.
 func (g Object) Within(o Object) bool {
-	return g.bridge().Within(o.bridge())
+	v, ok := g.view()
+	if !ok {
+		return false
+	}
+	ov, ok := o.view()
+	return ok && v.within(ov)
 }
 
 // Intersects detects if the object intersects another object.
 func (g Object) Intersects(o Object) bool {
-	return g.bridge().Intersects(o.bridge())
+	v, ok := g.view()
+	if !ok {
+		return false
+	}
+	ov, ok := o.view()
+	return ok && v.intersects(ov)
 }
 
 // Nearby detects if the object is nearby a position.
 func (g Object) Nearby(center Position, meters float64) bool {
-	return g.bridge().Nearby(
-		geojson.Position{center.X, center.Y, center.Z}, meters,
-	)
+	v, ok := g.view()
+	return ok && v.nearby(center, meters)
 }
 
 // CalculatedBBox is exterior bbox containing the object.
@@ -148,6 +153,19 @@ func (o Object) bridge() geojson.Object {
 		// object is a string
 		return geojson.String(o.String())
 	}
+	if !o.valid() {
+		return geojson.String("") // invalid
+	}
+	return o.bridgeValid()
+}
+
+// bridgeValid converts an object, which must already be validated, to a
+// geojson object.
+func (o Object) bridgeValid() geojson.Object {
+	if o.isCompact() {
+		o = o.expand()
+	}
+	tail := o.data[len(o.data)-1]
 	var dims int
 	var bboxSize int
 	if tail>>1&1 == 1 {
@@ -195,12 +213,8 @@ func (o Object) bridge() geojson.Object {
 			return geojson.SimplePoint{p.X, p.Y}
 		}
 	}
-	var exsz int
-	if tail>>4&1 == 1 {
-		// has exdata, skip over
-		exsz = int(binary.LittleEndian.Uint32(o.data[len(o.data)-5:]))
-	}
-	geomData := o.data[bboxSize+exsz:]
+	// the exdata follows the geom data and is never reached
+	geomData := o.data[bboxSize:]
 	geomHead := geomData[0]
 	geomType := GeometryType(geomHead >> 4)
 	geomData = geomData[1:]
@@ -275,7 +289,7 @@ func (o Object) bridge() geojson.Object {
 			sz := int(binary.LittleEndian.Uint32(geomData))
 			o := Object{geomData[4 : 4+sz : 4+sz]}
 			geomData = geomData[4+sz:]
-			objs[i] = o.bridge()
+			objs[i] = o.bridgeValid()
 		}
 		if geomType == GeometryCollection {
 			return geojson.GeometryCollection{objs, bbox}
@@ -284,7 +298,7 @@ func (o Object) bridge() geojson.Object {
 	case Feature:
 		sz := int(binary.LittleEndian.Uint32(geomData))
 		o := Object{geomData[4 : 4+sz : 4+sz]}
-		geom := o.bridge()
+		geom := o.bridgeValid()
 		return geojson.Feature{
 			Geometry: geom,
 			BBox:     bbox,
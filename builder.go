@@ -0,0 +1,145 @@
+package geobin
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+// The Make functions build complex geometries without going through GeoJSON.
+// The resulting objects are identical to those returned by ParseJSON for the
+// equivalent GeoJSON. Positions are 3D when at least one of them has a
+// non-zero Z, otherwise they're 2D.
+
+// MakeMultiPoint returns a MultiPoint object.
+func MakeMultiPoint(points []Position) Object {
+	dims := positionsDims(2, points)
+	vals, min, max := valsFromPositions1(points, dims, baseMin, baseMax)
+	return level1Object(MultiPoint, gjson.Result{}, vals, dims, min, max)
+}
+
+// MakeLineString returns a LineString object.
+func MakeLineString(points []Position) Object {
+	dims := positionsDims(2, points)
+	vals, min, max := valsFromPositions1(points, dims, baseMin, baseMax)
+	return level1Object(LineString, gjson.Result{}, vals, dims, min, max)
+}
+
+// MakeMultiLineString returns a MultiLineString object.
+func MakeMultiLineString(lines [][]Position) Object {
+	dims := positionsDims(2, lines...)
+	vals, min, max := valsFromPositions2(lines, dims, baseMin, baseMax)
+	return level2Object(MultiLineString, gjson.Result{}, vals, dims, min, max)
+}
+
+// MakePolygon returns a Polygon object with an exterior ring and optional
+// holes.
+func MakePolygon(exterior []Position, holes ...[]Position) Object {
+	rings := make([][]Position, 0, 1+len(holes))
+	rings = append(rings, exterior)
+	rings = append(rings, holes...)
+	dims := positionsDims(2, rings...)
+	vals, min, max := valsFromPositions2(rings, dims, baseMin, baseMax)
+	return level2Object(Polygon, gjson.Result{}, vals, dims, min, max)
+}
+
+// MakeMultiPolygon returns a MultiPolygon object. Each polygon is a series of
+// rings where the first ring is the exterior and the rest are holes.
+func MakeMultiPolygon(polygons [][][]Position) Object {
+	dims := 2
+	for _, rings := range polygons {
+		dims = positionsDims(dims, rings...)
+	}
+	vals := make([][][][3]float64, len(polygons))
+	min, max := baseMin, baseMax
+	for i, rings := range polygons {
+		vals[i], min, max = valsFromPositions2(rings, dims, min, max)
+	}
+	return level3Object(MultiPolygon, gjson.Result{}, vals, dims, min, max)
+}
+
+// MakeGeometryCollection returns a GeometryCollection object. Objects that are
+// not geometries are ignored.
+func MakeGeometryCollection(geoms ...Object) Object {
+	return collectionObject(GeometryCollection, gjson.Result{}, onlyGeometries(geoms))
+}
+
+// MakeFeatureCollection returns a FeatureCollection object. Objects that are
+// not Features are ignored.
+func MakeFeatureCollection(features ...Object) Object {
+	feats := make([]Object, 0, len(features))
+	for _, o := range features {
+		if o.IsGeometry() && o.GeometryType() == Feature {
+			feats = append(feats, o)
+		}
+	}
+	return collectionObject(FeatureCollection, gjson.Result{}, feats)
+}
+
+// MakeFeature returns a Feature object. The id and props params are raw JSON
+// for the "id" and "properties" members, and are omitted when empty.
+// Returns an empty object if geom is not a geometry, or if id or props is
+// not valid JSON.
+func MakeFeature(geom Object, id, props string) Object {
+	if !geom.IsGeometry() || (id != "" && !gjson.Valid(id)) ||
+		(props != "" && !gjson.Valid(props)) {
+		return Object{}
+	}
+	var idRes, propsRes gjson.Result
+	if id != "" {
+		idRes = gjson.Parse(id)
+	}
+	if props != "" {
+		propsRes = gjson.Parse(props)
+	}
+	return featureObject(gjson.Result{}, geom, idRes, propsRes)
+}
+
+func onlyGeometries(objs []Object) []Object {
+	for i := 0; i < len(objs); i++ {
+		if !objs[i].IsGeometry() {
+			geoms := make([]Object, 0, len(objs))
+			for _, o := range objs {
+				if o.IsGeometry() {
+					geoms = append(geoms, o)
+				}
+			}
+			return geoms
+		}
+	}
+	return objs
+}
+
+// positionsDims returns 3 if any position has a Z, otherwise dims.
+func positionsDims(dims int, series ...[]Position) int {
+	for _, points := range series {
+		for _, p := range points {
+			if p.Z != 0 {
+				return 3
+			}
+		}
+	}
+	return dims
+}
+
+func valsFromPositions1(points []Position, dims int, min, max [3]float64) (vals [][3]float64, minOut, maxOut [3]float64) {
+	vals = make([][3]float64, len(points))
+	for i, p := range points {
+		vals[i] = [3]float64{p.X, p.Y, p.Z}
+		for j := 0; j < dims; j++ {
+			if vals[i][j] < min[j] {
+				min[j] = vals[i][j]
+			}
+			if vals[i][j] > max[j] {
+				max[j] = vals[i][j]
+			}
+		}
+	}
+	return vals, min, max
+}
+
+func valsFromPositions2(series [][]Position, dims int, min, max [3]float64) (vals [][][3]float64, minOut, maxOut [3]float64) {
+	vals = make([][][3]float64, len(series))
+	for i, points := range series {
+		vals[i], min, max = valsFromPositions1(points, dims, min, max)
+	}
+	return vals, min, max
+}
@@ -0,0 +1,72 @@
+package geobin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertSameBinary(t *testing.T, json string, o Object) {
+	t.Helper()
+	expect := ParseJSON(json)
+	if !bytes.Equal(expect.Binary(), o.Binary()) {
+		t.Fatalf("binary mismatch\nexpected: %s\n     got: %s", expect.JSON(), o.JSON())
+	}
+}
+
+func TestMakeLevels(t *testing.T) {
+	assertSameBinary(t, `{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}`,
+		MakeMultiPoint([]Position{P(1, 2), P(3, 4)}))
+	assertSameBinary(t, `{"type":"LineString","coordinates":[[1,2,3],[3,4,5]]}`,
+		MakeLineString([]Position{P3(1, 2, 3), P3(3, 4, 5)}))
+	assertSameBinary(t, `{"type":"LineString","coordinates":[]}`,
+		MakeLineString(nil))
+	assertSameBinary(t, `{"type":"MultiLineString","coordinates":[[[1,2],[3,4]],[[5,6],[7,8]]]}`,
+		MakeMultiLineString([][]Position{{P(1, 2), P(3, 4)}, {P(5, 6), P(7, 8)}}))
+	assertSameBinary(t, testPolyHoles,
+		MakePolygon(
+			[]Position{P(0, 0), P(0, 6), P(12, -6), P(12, 0), P(0, 0)},
+			[]Position{P(1, 1), P(1, 2), P(2, 2), P(2, 1), P(1, 1)},
+			[]Position{P(11, -1), P(11, -3), P(9, -1), P(11, -1)},
+		))
+	assertSameBinary(t, `{"type":"MultiPolygon","coordinates":[
+		[[[0,0,1],[0,1,1],[1,1,1],[0,0,1]]],
+		[[[5,5,2],[5,6,2],[6,6,2],[5,5,2]],[[5.1,5.5,2],[5.2,5.5,2],[5.2,5.6,2],[5.1,5.5,2]]]
+	]}`, MakeMultiPolygon([][][]Position{
+		{{P3(0, 0, 1), P3(0, 1, 1), P3(1, 1, 1), P3(0, 0, 1)}},
+		{
+			{P3(5, 5, 2), P3(5, 6, 2), P3(6, 6, 2), P3(5, 5, 2)},
+			{P3(5.1, 5.5, 2), P3(5.2, 5.5, 2), P3(5.2, 5.6, 2), P3(5.1, 5.5, 2)},
+		},
+	}))
+}
+
+func TestMakeFeatures(t *testing.T) {
+	line := MakeLineString([]Position{P(1, 2), P(3, 4)})
+	assertSameBinary(t, `{"type":"Feature","id":"a1","properties":{"a": 1, "b":[1, 2]},
+		"geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]}}`,
+		MakeFeature(line, `"a1"`, `{"a": 1, "b":[1, 2]}`))
+	assertSameBinary(t, `{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}`,
+		MakeFeature(Make2DPoint(1, 2), "", ""))
+	assertSameBinary(t, `{"type":"Feature","properties":null,"geometry":{"type":"Point","coordinates":[1,2]}}`,
+		MakeFeature(Make2DPoint(1, 2), "", "null"))
+	assert.Equal(t, Object{}, MakeFeature(MakeString("hello"), "", ""))
+	assert.Equal(t, Object{}, MakeFeature(Make2DPoint(1, 2), "", `{"a":`))
+	assert.Equal(t, Object{}, MakeFeature(Make2DPoint(1, 2), "", "not json"))
+	assert.Equal(t, Object{}, MakeFeature(Make2DPoint(1, 2), "1 2", ""))
+
+	assertSameBinary(t, `{"type":"GeometryCollection","geometries":[
+		{"type":"Point","coordinates":[1,2,3]},
+		{"type":"LineString","coordinates":[[1,2],[3,4]]}
+	]}`, MakeGeometryCollection(Make3DPoint(1, 2, 3), MakeString("skip"), line))
+	assertSameBinary(t, `{"type":"FeatureCollection","features":[
+		{"type":"Feature","id":1,"geometry":{"type":"Point","coordinates":[1,2]}},
+		{"type":"Feature","geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]}}
+	]}`, MakeFeatureCollection(
+		MakeFeature(Make2DPoint(1, 2), "1", ""),
+		Make2DPoint(5, 6),
+		MakeString("skip"),
+		MakeFeature(line, "", ""),
+	))
+}
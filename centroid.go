@@ -0,0 +1,196 @@
+package geobin
+
+import (
+	"math"
+	"sort"
+)
+
+// Centroid returns the centroid of the object. It's the area weighted
+// centroid of the polygons, or when there are no polygons the length
+// weighted centroid of the lines, or otherwise the average of the points.
+// Collections and features use all of their children. The centroid is
+// planar and the Z is not calculated. Returns the same as Position for
+// objects without positions.
+func (o Object) Centroid() Position {
+	var c centroid
+	o.ForEachPart(func(part Geometry) bool {
+		c.addPart(part)
+		return true
+	})
+	switch {
+	case c.area != 0:
+		return Position{X: c.ax / c.area, Y: c.ay / c.area}
+	case c.length != 0:
+		return Position{X: c.lx / c.length, Y: c.ly / c.length}
+	case c.points != 0:
+		return Position{X: c.px / c.points, Y: c.py / c.points}
+	case c.vertices != 0:
+		// degenerate lines and polygons
+		return Position{X: c.vx / c.vertices, Y: c.vy / c.vertices}
+	}
+	return o.Position()
+}
+
+// centroid accumulates the weighted centroids of each dimension.
+type centroid struct {
+	area, ax, ay     float64
+	length, lx, ly   float64
+	points, px, py   float64
+	vertices, vx, vy float64
+}
+
+func (c *centroid) addPart(part Geometry) {
+	switch {
+	case part.Type == Point:
+		part.ForEachPosition(func(p Position) bool {
+			c.points++
+			c.px += p.X
+			c.py += p.Y
+			return true
+		})
+	case part.Type == LineString:
+		if r, _, ok := readRing(part.Data, part.Dims); ok {
+			c.addLine(r)
+		}
+	default:
+		forEachPolygonRing(part, func(i int, r RingReader) bool {
+			area, x, y := ringCentroid(r)
+			if i > 0 {
+				area = -math.Abs(area) // hole
+			} else {
+				area = math.Abs(area)
+			}
+			c.area += area
+			c.ax += area * x
+			c.ay += area * y
+			c.addLine(r)
+			return true
+		})
+	}
+}
+
+// addLine adds the segments and vertices of a line.
+func (c *centroid) addLine(r RingReader) {
+	for i := 0; i < r.Len(); i++ {
+		b := r.At(i)
+		c.vertices++
+		c.vx += b.X
+		c.vy += b.Y
+		if i == 0 {
+			continue
+		}
+		a := r.At(i - 1)
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		c.length += length
+		c.lx += length * (a.X + b.X) / 2
+		c.ly += length * (a.Y + b.Y) / 2
+	}
+}
+
+// ringCentroid returns the signed planar area and the centroid of a ring.
+func ringCentroid(r RingReader) (area, x, y float64) {
+	n := r.Len()
+	if n < 3 {
+		return 0, 0, 0
+	}
+	// relative to the first position for precision
+	o := r.At(0)
+	var area2, cx, cy float64
+	for i := 0; i < n; i++ {
+		a, b := r.At(i), r.At((i+1)%n)
+		ax, ay := a.X-o.X, a.Y-o.Y
+		bx, by := b.X-o.X, b.Y-o.Y
+		cross := ax*by - bx*ay
+		area2 += cross
+		cx += (ax + bx) * cross
+		cy += (ay + by) * cross
+	}
+	if area2 == 0 {
+		return 0, 0, 0
+	}
+	return area2 / 2, cx/(3*area2) + o.X, cy/(3*area2) + o.Y
+}
+
+// PointOnSurface returns a position that is guaranteed to be on the object.
+// For polygons it's inside of the polygon, away from the edges, in the
+// widest part of a horizontal line through the middle of the polygon with
+// the largest such part. For lines and points it's the position that is
+// nearest to the centroid. Returns the same as Position for objects without
+// positions.
+func (o Object) PointOnSurface() Position {
+	var best Position
+	var width float64
+	var rings []RingReader
+	o.ForEachPart(func(part Geometry) bool {
+		rings = rings[:0]
+		forEachPolygonRing(part, func(_ int, r RingReader) bool {
+			rings = append(rings, r)
+			return true
+		})
+		if p, w, ok := interiorPoint(rings); ok && w > width {
+			best, width = p, w
+		}
+		return true
+	})
+	if width > 0 {
+		return best
+	}
+	// nearest position to the centroid
+	c := o.Centroid()
+	dist := math.Inf(1)
+	o.ForEachPosition(func(p Position) bool {
+		if d := math.Hypot(p.X-c.X, p.Y-c.Y); d < dist {
+			best, dist = p, d
+		}
+		return true
+	})
+	if math.IsInf(dist, 1) {
+		return o.Position()
+	}
+	return best
+}
+
+// interiorPoint returns the middle of the widest interior part of a scan
+// line through a polygon. The scan line is placed between the vertices
+// nearest to the middle of the exterior ring, so it never crosses a vertex.
+func interiorPoint(rings []RingReader) (Position, float64, bool) {
+	if len(rings) == 0 || rings[0].Len() == 0 {
+		return Position{}, 0, false
+	}
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	rings[0].ForEachPosition(func(p Position) bool {
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		return true
+	})
+	mid := (minY + maxY) / 2
+	lo, hi := minY, maxY
+	for _, r := range rings {
+		r.ForEachPosition(func(p Position) bool {
+			if p.Y <= mid {
+				lo = math.Max(lo, p.Y)
+			} else {
+				hi = math.Min(hi, p.Y)
+			}
+			return true
+		})
+	}
+	y := (lo + hi) / 2
+	var xs []float64
+	for _, r := range rings {
+		for i := 1; i < r.Len(); i++ {
+			a, b := r.At(i-1), r.At(i)
+			if (a.Y > y) != (b.Y > y) {
+				xs = append(xs, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+	}
+	sort.Float64s(xs)
+	var p Position
+	var width float64
+	for i := 1; i < len(xs); i += 2 {
+		if w := xs[i] - xs[i-1]; w > width {
+			p, width = Position{X: (xs[i] + xs[i-1]) / 2, Y: y}, w
+		}
+	}
+	return p, width, width > 0
+}
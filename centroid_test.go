@@ -0,0 +1,92 @@
+package geobin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertNearPosition(t *testing.T, expect, actual Position, msgAndArgs ...interface{}) {
+	t.Helper()
+	assert.InDelta(t, expect.X, actual.X, 1e-9, msgAndArgs...)
+	assert.InDelta(t, expect.Y, actual.Y, 1e-9, msgAndArgs...)
+	assert.InDelta(t, expect.Z, actual.Z, 1e-9, msgAndArgs...)
+}
+
+const testLShape = `{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,2],[2,2],[2,10],[0,10],[0,0]]]}`
+
+func TestCentroid(t *testing.T) {
+	var tests = []struct {
+		js     string
+		expect Position
+	}{
+		{`{"type":"Point","coordinates":[1,2]}`, P(1, 2)},
+		{`{"type":"MultiPoint","coordinates":[[0,0],[2,0],[4,6]]}`, P(2, 2)},
+		{`{"type":"LineString","coordinates":[[0,0],[10,0],[10,1]]}`, P(60.0/11, 0.5/11)},
+		{`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,2],[0,2],[0,0]]]}`, P(2, 1)},
+		{`{"type":"Polygon","coordinates":[[[0,0],[0,2],[4,2],[4,0],[0,0]]]}`, P(2, 1)},
+		// L shape, 20 at (5,1) and 16 at (1,6)
+		{testLShape, P((20*5+16*1)/36.0, (20*1+16*6)/36.0)},
+		// hole on the right half
+		{`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[2,0],[4,0],[4,4],[2,4],[2,0]]]}`, P(1, 2)},
+		// polygons win over lines and points
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[100,100]},
+			{"type":"LineString","coordinates":[[50,50],[60,60]]},
+			{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,2],[0,2],[0,0]]]},
+			{"type":"Polygon","coordinates":[[[10,0],[14,0],[14,2],[10,2],[10,0]]]}]}`, P(7, 1)},
+		{`{"type":"Feature","geometry":{"type":"LineString","coordinates":[[0,0],[2,2]]}}`, P(1, 1)},
+		// degenerate
+		{`{"type":"Polygon","coordinates":[[[1,1],[3,3],[1,1],[1,1]]]}`, P(2, 2)},
+		{`{"type":"LineString","coordinates":[[1,1],[1,1]]}`, P(1, 1)},
+	}
+	for _, test := range tests {
+		o := ParseJSON(test.js)
+		assertNearPosition(t, test.expect, o.Centroid(), test.js)
+		assertNearPosition(t, test.expect, o.Compact(1e-9).Centroid(), test.js)
+	}
+	assertNearPosition(t, P(2, 3), Make2DRect(1, 2, 3, 4).Centroid())
+	assertNearPosition(t, P(2, 3), Make3DRect(1, 2, 5, 3, 4, 6).Centroid())
+	assertNearPosition(t, P(1, 2), Make3DPoint(1, 2, 3).Centroid())
+	c := ParseJSON(`{"type":"MultiPolygon","coordinates":[]}`).Centroid()
+	assert.True(t, math.IsNaN(c.X) && math.IsNaN(c.Y))
+}
+
+func TestPointOnSurface(t *testing.T) {
+	for _, js := range []string{
+		testLShape,
+		testPolyHoles,
+		`{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[1,1],[9,1],[9,9],[1,9],[1,1]]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[8,10],[8,2],[2,2],[2,10],[0,10],[0,0]]]}`,
+		`{"type":"Feature","geometry":` + testLShape + `}`,
+	} {
+		o := ParseJSON(js)
+		p := o.PointOnSurface()
+		assert.True(t, Make2DPoint(p.X, p.Y).Within(o), "%s: %v", js, p)
+	}
+	// the centroid of the L shape is outside, the point on surface is not
+	l := ParseJSON(testLShape)
+	c := l.Centroid()
+	assert.False(t, Make2DPoint(c.X, c.Y).Within(l))
+	assertNearPosition(t, P(15, 2.5), ParseJSON(`{"type":"MultiPolygon","coordinates":[
+		[[[0,0],[1,0],[1,1],[0,1],[0,0]]],[[[10,0],[20,0],[20,5],[10,5],[10,0]]]]}`).PointOnSurface())
+	assertNearPosition(t, P(2, 3), Make2DRect(1, 2, 3, 4).PointOnSurface())
+
+	// lines and points use the nearest position to the centroid
+	assertNearPosition(t, P(1, 1), ParseJSON(
+		`{"type":"LineString","coordinates":[[0,0],[1,1],[5,0]]}`).PointOnSurface())
+	assertNearPosition(t, P3(2, 0, 7), ParseJSON(
+		`{"type":"MultiPoint","coordinates":[[0,0,1],[2,0,7],[5,0,3]]}`).PointOnSurface())
+	p := ParseJSON(`{"type":"MultiPoint","coordinates":[]}`).PointOnSurface()
+	assert.True(t, math.IsNaN(p.X) && math.IsNaN(p.Y))
+}
+
+func TestCentroidTruncated(t *testing.T) {
+	// simple objects without their bbox
+	for _, data := range [][]byte{{1, 1}, {1, 3}, {1, 5}, {1, 7}} {
+		assert.NotPanics(t, func() {
+			WrapBinary(data).Centroid()
+			WrapBinary(data).PointOnSurface()
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package geobin
+
+// NumChildren returns the number of geometries in a GeometryCollection or
+// features in a FeatureCollection. Returns zero for all other objects.
+func (o Object) NumChildren() int {
+	n, _ := o.childrenData()
+	return n
+}
+
+// Child returns the child at index i of a GeometryCollection or
+// FeatureCollection. The child shares the data of the collection. Returns
+// an empty object if i is out of range. Finding the child is O(i), use
+// ForEachChild to visit all of the children.
+func (o Object) Child(i int) Object {
+	var child Object
+	o.ForEachChild(func(j int, c Object) bool {
+		if j == i {
+			child = c
+			return false
+		}
+		return true
+	})
+	return child
+}
+
+// ForEachChild iterates over the children of a GeometryCollection or
+// FeatureCollection. The children share the data of the collection. Return
+// false from the iterator to stop.
+func (o Object) ForEachChild(iter func(i int, child Object) bool) {
+	n, data := o.childrenData()
+	for i := 0; i < n; i++ {
+		if len(data) < 4 {
+			return // invalid
+		}
+		var sz int
+		sz, data = readUint32(data)
+		if sz > len(data) {
+			return // invalid
+		}
+		if !iter(i, Object{data[:sz:sz]}) {
+			return
+		}
+		data = data[sz:]
+	}
+}
+
+// FeatureGeometry returns the geometry of a Feature. The geometry shares the
+// data of the feature. Returns an empty object if the object is not a
+// Feature.
+func (o Object) FeatureGeometry() Object {
+	g := o.Geometry()
+	if g.Simple || g.Type != Feature || len(g.Data) < 4 {
+		return Object{}
+	}
+	sz, data := readUint32(g.Data)
+	if sz > len(data) {
+		return Object{} // invalid
+	}
+	return Object{data[:sz:sz]}
+}
+
+// childrenData returns the number of children and the packed
+// [UINT32][OBJECT] data that follows for a collection.
+func (o Object) childrenData() (int, []byte) {
+	g := o.Geometry()
+	if g.Simple || (g.Type != GeometryCollection && g.Type != FeatureCollection) ||
+		len(g.Data) < 4 {
+		return 0, nil
+	}
+	return readUint32(g.Data)
+}
@@ -0,0 +1,58 @@
+package geobin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChildren(t *testing.T) {
+	fc := ParseJSON(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","id":1,"geometry":{"type":"Point","coordinates":[1,2]}},
+		{"type":"Feature","id":2,"geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]}}
+	]}`)
+	assert.Equal(t, 2, fc.NumChildren())
+	assert.Equal(t, `{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"id":1}`, fc.Child(0).JSON())
+	assert.Equal(t, `{"type":"Feature","geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]},"id":2}`, fc.Child(1).JSON())
+	assert.Equal(t, Object{}, fc.Child(2))
+	assert.Equal(t, Object{}, fc.Child(-1))
+	assert.Equal(t, `{"type":"LineString","coordinates":[[1,2],[3,4]]}`, fc.Child(1).FeatureGeometry().JSON())
+
+	// zero-copy
+	data := fc.Binary()
+	child := fc.Child(1).Binary()
+	assert.True(t, &data[bytes.Index(data, child)] == &child[0])
+
+	var idxs []int
+	fc.ForEachChild(func(i int, child Object) bool {
+		idxs = append(idxs, i)
+		return false
+	})
+	assert.Equal(t, []int{0}, idxs)
+
+	gc := ParseJSON(`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2,3]}]}`)
+	assert.Equal(t, 1, gc.NumChildren())
+	assert.Equal(t, Make3DPoint(1, 2, 3), gc.Child(0))
+	assert.Equal(t, Object{}, gc.FeatureGeometry())
+
+	for _, o := range []Object{{}, MakeString("hello"), Make2DRect(1, 2, 3, 4), fc.Child(0),
+		ParseJSON(`{"type":"MultiPoint","coordinates":[[1,2]]}`)} {
+		assert.Equal(t, 0, o.NumChildren())
+		assert.Equal(t, Object{}, o.Child(0))
+	}
+	assert.Equal(t, Make2DPoint(1, 2), fc.Child(0).FeatureGeometry())
+}
+
+func TestChildrenMalformed(t *testing.T) {
+	for _, o := range testValidateObjects() {
+		data := o.Binary()
+		for i := 0; i < len(data); i++ {
+			bad := WrapBinary(data[:i])
+			bad.NumChildren()
+			bad.Child(1)
+			bad.FeatureGeometry()
+			bad.ForEachChild(func(int, Object) bool { return true })
+		}
+	}
+}
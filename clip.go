@@ -0,0 +1,357 @@
+package geobin
+
+import (
+	"math"
+
+	"github.com/tidwall/gjson"
+)
+
+// ClipToBBox returns a copy of the object with only the parts that are
+// inside the bbox. Points outside of the bbox are removed, lines are cut at
+// the edges of the bbox, and the rings of polygons are clipped to the bbox.
+// The bbox is recalculated, an exported bbox stays exported with the new
+// values, and the members and exdata are kept. Only the X and Y of the bbox
+// are used. When nothing overlaps, an empty geometry of
+// the same type is returned, or an empty MultiPoint for a Point. Children of
+// collections that become empty are removed.
+func (o Object) ClipToBBox(bbox BBox) Object {
+	if !o.IsGeometry() || !o.valid() {
+		return o
+	}
+	if o.positionsInside(bbox) {
+		return o
+	}
+	return clipObject(o, bbox)
+}
+
+// positionsInside returns true if every position is inside the bbox.
+func (o Object) positionsInside(bbox BBox) bool {
+	inside := true
+	o.ForEachPosition(func(p Position) bool {
+		inside = pointInsideRect(p, bbox)
+		return inside
+	})
+	return inside
+}
+
+// clipObject clips a valid object that is not within the bbox.
+func clipObject(o Object, bbox BBox) Object {
+	g := o.Geometry()
+	dims := g.Dims
+	if g.Simple {
+		if g.Type == Point {
+			return emptyGeometry(MultiPoint, dims)
+		}
+		min, max := o.Rect(nil)
+		minX, minY := math.Max(min[0], bbox.Min.X), math.Max(min[1], bbox.Min.Y)
+		maxX, maxY := math.Min(max[0], bbox.Max.X), math.Min(max[1], bbox.Max.Y)
+		if minX > maxX || minY > maxY {
+			return emptyGeometry(g.Type, dims)
+		}
+		if dims == 3 {
+			return Make3DRect(minX, minY, min[2], maxX, maxY, max[2])
+		}
+		return Make2DRect(minX, minY, maxX, maxY)
+	}
+	var c Object
+	switch g.Type {
+	case Point:
+		c = emptyGeometry(MultiPoint, dims)
+	case MultiPoint:
+		r, _, _ := readRing(g.Data, dims)
+		var points []Position
+		r.ForEachPosition(func(p Position) bool {
+			if pointInsideRect(p, bbox) {
+				points = append(points, p)
+			}
+			return true
+		})
+		c = makeLevel1(MultiPoint, points, dims)
+	case LineString, MultiLineString:
+		var lines [][]Position
+		g.ForEachRing(func(r RingReader) bool {
+			lines = clipLine(lines, r, bbox)
+			return true
+		})
+		switch {
+		case g.Type == MultiLineString || len(lines) > 1:
+			c = makeLevel2(MultiLineString, lines, dims)
+		case len(lines) == 1:
+			c = makeLevel1(LineString, lines[0], dims)
+		default:
+			c = emptyGeometry(LineString, dims)
+		}
+	case Polygon, MultiPolygon:
+		var polys [][][]Position
+		g.ForEachPart(func(part Geometry) bool {
+			var rings [][]Position
+			forEachPolygonRing(part, func(i int, r RingReader) bool {
+				ring := clipRing(r, bbox)
+				if i == 0 && ring == nil {
+					return false // exterior is outside
+				}
+				if ring != nil {
+					rings = append(rings, ring)
+				}
+				return true
+			})
+			if len(rings) > 0 {
+				polys = append(polys, rings)
+			}
+			return true
+		})
+		switch {
+		case g.Type == MultiPolygon:
+			c = makeLevel3(MultiPolygon, polys, dims)
+		case len(polys) == 1:
+			c = makeLevel2(Polygon, polys[0], dims)
+		default:
+			c = emptyGeometry(Polygon, dims)
+		}
+	case Feature:
+		geom := clipObject(o.FeatureGeometry(), bbox)
+		c = featureObject(gjson.Result{}, geom, gjson.Result{}, gjson.Result{})
+	case GeometryCollection, FeatureCollection:
+		var children []Object
+		o.ForEachChild(func(_ int, child Object) bool {
+			if !child.positionsInside(bbox) {
+				if !child.IntersectsBBox(bbox) {
+					return true
+				}
+				child = clipObject(child, bbox)
+			}
+			if !child.isEmpty() {
+				children = append(children, child)
+			}
+			return true
+		})
+		c = collectionObject(g.Type, gjson.Result{}, children)
+	default:
+		return o
+	}
+	if o.parseComponents().data[0]>>1&1 == 1 && !c.isEmpty() {
+		// keep the exported bbox, which has the clipped values
+		cc := c.parseComponents()
+		cc.data = append([]byte{cc.data[0] | 2}, cc.data[1:]...)
+		c = cc.reconstructObject()
+	}
+	if members := o.Members(); len(members) > 0 {
+		c = c.complexPoint().setMembers(members)
+	}
+	if exdata := o.ExData(); len(exdata) > 0 {
+		c = c.SetExData(exdata)
+	}
+	return c
+}
+
+// isEmpty returns true if the geometry has no positions.
+func (o Object) isEmpty() bool {
+	empty := true
+	o.ForEachPosition(func(Position) bool {
+		empty = false
+		return false
+	})
+	return empty
+}
+
+// emptyGeometry returns a geometry without coordinates.
+func emptyGeometry(typ GeometryType, dims int) Object {
+	switch typ {
+	case Polygon, MultiLineString:
+		return makeLevel2(typ, nil, dims)
+	case MultiPolygon:
+		return makeLevel3(typ, nil, dims)
+	}
+	return makeLevel1(typ, nil, dims)
+}
+
+func makeLevel1(typ GeometryType, points []Position, dims int) Object {
+	vals, min, max := valsFromPositions1(points, dims, baseMin, baseMax)
+	return level1Object(typ, gjson.Result{}, vals, dims, min, max)
+}
+
+func makeLevel2(typ GeometryType, series [][]Position, dims int) Object {
+	vals, min, max := valsFromPositions2(series, dims, baseMin, baseMax)
+	return level2Object(typ, gjson.Result{}, vals, dims, min, max)
+}
+
+func makeLevel3(typ GeometryType, polys [][][]Position, dims int) Object {
+	vals := make([][][][3]float64, len(polys))
+	min, max := baseMin, baseMax
+	for i, rings := range polys {
+		vals[i], min, max = valsFromPositions2(rings, dims, min, max)
+	}
+	return level3Object(typ, gjson.Result{}, vals, dims, min, max)
+}
+
+// outcode bits for Cohen-Sutherland
+const (
+	clipLeft = 1 << iota
+	clipRight
+	clipBottom
+	clipTop
+)
+
+func outcode(p Position, bbox BBox) int {
+	var code int
+	if p.X < bbox.Min.X {
+		code |= clipLeft
+	} else if p.X > bbox.Max.X {
+		code |= clipRight
+	}
+	if p.Y < bbox.Min.Y {
+		code |= clipBottom
+	} else if p.Y > bbox.Max.Y {
+		code |= clipTop
+	}
+	return code
+}
+
+// lerp returns the position at t along the segment ab, including the Z.
+func lerp(a, b Position, t float64) Position {
+	return Position{
+		X: a.X + (b.X-a.X)*t,
+		Y: a.Y + (b.Y-a.Y)*t,
+		Z: a.Z + (b.Z-a.Z)*t,
+	}
+}
+
+// clipSegment clips the segment ab to the bbox using Cohen-Sutherland.
+// Returns false if the segment is outside.
+func clipSegment(a, b Position, bbox BBox) (Position, Position, bool) {
+	ca, cb := outcode(a, bbox), outcode(b, bbox)
+	for {
+		if ca|cb == 0 {
+			return a, b, true
+		}
+		if ca&cb != 0 {
+			return a, b, false
+		}
+		code := ca
+		if code == 0 {
+			code = cb
+		}
+		var t float64
+		var edge float64
+		var x bool
+		switch {
+		case code&clipTop != 0:
+			edge, t = bbox.Max.Y, (bbox.Max.Y-a.Y)/(b.Y-a.Y)
+		case code&clipBottom != 0:
+			edge, t = bbox.Min.Y, (bbox.Min.Y-a.Y)/(b.Y-a.Y)
+		case code&clipRight != 0:
+			edge, t, x = bbox.Max.X, (bbox.Max.X-a.X)/(b.X-a.X), true
+		default:
+			edge, t, x = bbox.Min.X, (bbox.Min.X-a.X)/(b.X-a.X), true
+		}
+		p := lerp(a, b, t)
+		// snap to the edge to avoid rounding outside
+		if x {
+			p.X = edge
+		} else {
+			p.Y = edge
+		}
+		if code == ca {
+			a, ca = p, outcode(p, bbox)
+		} else {
+			b, cb = p, outcode(p, bbox)
+		}
+	}
+}
+
+// clipLine appends the parts of a line that are inside the bbox.
+func clipLine(lines [][]Position, r RingReader, bbox BBox) [][]Position {
+	var line []Position
+	for i := 1; i < r.Len(); i++ {
+		a, b := r.At(i-1), r.At(i)
+		ca, cb, ok := clipSegment(a, b, bbox)
+		if !ok {
+			continue
+		}
+		if len(line) == 0 || line[len(line)-1] != ca {
+			if len(line) > 1 {
+				lines = append(lines, line)
+			}
+			line = []Position{ca}
+		}
+		line = append(line, cb)
+		if cb != b {
+			// left the bbox
+			lines = append(lines, line)
+			line = nil
+		}
+	}
+	if len(line) > 1 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// clipRing clips a ring to the bbox using Sutherland-Hodgman. Returns nil
+// if nothing is left.
+func clipRing(r RingReader, bbox BBox) []Position {
+	ring := make([]Position, 0, r.Len())
+	for i := 0; i < r.Len(); i++ {
+		ring = append(ring, r.At(i))
+	}
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+	for edge := 0; edge < 4 && len(ring) > 0; edge++ {
+		inside := func(p Position) bool {
+			switch edge {
+			case 0:
+				return p.X >= bbox.Min.X
+			case 1:
+				return p.X <= bbox.Max.X
+			case 2:
+				return p.Y >= bbox.Min.Y
+			}
+			return p.Y <= bbox.Max.Y
+		}
+		cross := func(a, b Position) Position {
+			var p Position
+			switch edge {
+			case 0:
+				p = lerp(a, b, (bbox.Min.X-a.X)/(b.X-a.X))
+				p.X = bbox.Min.X
+			case 1:
+				p = lerp(a, b, (bbox.Max.X-a.X)/(b.X-a.X))
+				p.X = bbox.Max.X
+			case 2:
+				p = lerp(a, b, (bbox.Min.Y-a.Y)/(b.Y-a.Y))
+				p.Y = bbox.Min.Y
+			default:
+				p = lerp(a, b, (bbox.Max.Y-a.Y)/(b.Y-a.Y))
+				p.Y = bbox.Max.Y
+			}
+			return p
+		}
+		in := ring
+		ring = make([]Position, 0, len(in)+4)
+		add := func(p Position) {
+			if len(ring) == 0 || ring[len(ring)-1] != p {
+				ring = append(ring, p)
+			}
+		}
+		a := in[len(in)-1]
+		for _, b := range in {
+			if inside(b) {
+				if !inside(a) {
+					add(cross(a, b))
+				}
+				add(b)
+			} else if inside(a) {
+				add(cross(a, b))
+			}
+			a = b
+		}
+		if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+			ring = ring[:len(ring)-1]
+		}
+	}
+	if len(ring) < 3 {
+		return nil
+	}
+	return append(ring, ring[0])
+}
@@ -0,0 +1,112 @@
+package geobin
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipToBBox(t *testing.T) {
+	bbox := BBox{Min: P(0, 0), Max: P(10, 10)}
+	var tests = []struct{ in, out string }{
+		// within
+		{`{"type":"Point","coordinates":[1,2]}`, `{"type":"Point","coordinates":[1,2]}`},
+		{`{"type":"LineString","coordinates":[[1,1],[2,2]]}`, `{"type":"LineString","coordinates":[[1,1],[2,2]]}`},
+		// points
+		{`{"type":"Point","coordinates":[11,2]}`, `{"type":"MultiPoint","coordinates":[]}`},
+		{`{"type":"MultiPoint","coordinates":[[1,2],[11,2],[10,10]]}`, `{"type":"MultiPoint","coordinates":[[1,2],[10,10]]}`},
+		// lines
+		{`{"type":"LineString","coordinates":[[-5,5],[5,5],[5,15]]}`, `{"type":"LineString","coordinates":[[0,5],[5,5],[5,10]]}`},
+		{`{"type":"LineString","coordinates":[[-5,5],[5,5],[15,5],[15,8],[5,8]]}`,
+			`{"type":"MultiLineString","coordinates":[[[0,5],[5,5],[10,5]],[[10,8],[5,8]]]}`},
+		{`{"type":"LineString","coordinates":[[-5,5,0],[5,5,10]]}`, `{"type":"LineString","coordinates":[[0,5,5],[5,5,10]]}`},
+		{`{"type":"LineString","coordinates":[[-5,-5],[-5,15]]}`, `{"type":"LineString","coordinates":[]}`},
+		{`{"type":"MultiLineString","coordinates":[[[-5,5],[5,5]],[[20,20],[30,30]]]}`,
+			`{"type":"MultiLineString","coordinates":[[[0,5],[5,5]]]}`},
+		// polygons
+		{`{"type":"Polygon","coordinates":[[[-5,-5],[5,-5],[5,5],[-5,5],[-5,-5]]]}`,
+			`{"type":"Polygon","coordinates":[[[0,0],[5,0],[5,5],[0,5],[0,0]]]}`},
+		{`{"type":"Polygon","coordinates":[[[-5,-5],[15,-5],[15,15],[-5,15],[-5,-5]],[[1,1],[2,1],[2,2],[1,2],[1,1]],[[20,20],[21,20],[21,21],[20,20]]]}`,
+			`{"type":"Polygon","coordinates":[[[0,10],[0,0],[10,0],[10,10],[0,10]],[[1,1],[2,1],[2,2],[1,2],[1,1]]]}`},
+		{`{"type":"Polygon","coordinates":[[[20,20],[21,20],[21,21],[20,20]]]}`, `{"type":"Polygon","coordinates":[]}`},
+		{`{"type":"MultiPolygon","coordinates":[[[[-5,-5],[5,-5],[5,5],[-5,-5]]],[[[20,20],[21,20],[21,21],[20,20]]]]}`,
+			`{"type":"MultiPolygon","coordinates":[[[[0,0],[5,0],[5,5],[0,0]]]]}`},
+		// collections
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"Point","coordinates":[11,2]},
+			{"type":"LineString","coordinates":[[-5,5],[5,5]]}]}`,
+			`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[0,5],[5,5]]}]}`},
+		{`{"type":"Feature","id":1,"properties":{"a":1},"geometry":{"type":"LineString","coordinates":[[-5,5],[5,5]]}}`,
+			`{"type":"Feature","geometry":{"type":"LineString","coordinates":[[0,5],[5,5]]},"id":1,"properties":{"a":1}}`},
+		{`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[11,2]}}]}`,
+			`{"type":"FeatureCollection","features":[]}`},
+	}
+	for _, test := range tests {
+		o := ParseJSON(test.in)
+		c := o.ClipToBBox(bbox)
+		assert.Equal(t, test.out, c.JSON(), test.in)
+		assert.NoError(t, Validate(c.Binary()), test.in)
+		assert.Equal(t, test.out, o.Compact(1e-7).ClipToBBox(bbox).Expand().JSON(), test.in)
+	}
+
+	// simple objects
+	assert.Equal(t, Make2DRect(5, 5, 10, 10), Make2DRect(5, 5, 15, 15).ClipToBBox(bbox))
+	assert.Equal(t, Make3DRect(5, 5, 1, 10, 10, 2), Make3DRect(5, 5, 1, 15, 15, 2).ClipToBBox(bbox))
+	assert.Equal(t, `{"type":"Polygon","coordinates":[]}`, Make2DRect(15, 15, 20, 20).ClipToBBox(bbox).JSON())
+	assert.Equal(t, `{"type":"MultiPoint","coordinates":[]}`, Make2DPoint(15, 15).ClipToBBox(bbox).JSON())
+	assert.Equal(t, MakeString("hello"), MakeString("hello").ClipToBBox(bbox))
+
+	// the bbox is recalculated and the exdata is kept
+	o := ParseJSON(`{"type":"LineString","bbox":[-5,5,5,5],"coordinates":[[-5,5],[5,5]]}`).SetExData([]byte("hi"))
+	c := o.ClipToBBox(bbox)
+	min, max := c.Rect(nil)
+	assert.Equal(t, [3]float64{0, 5, 0}, min)
+	assert.Equal(t, [3]float64{5, 5, 0}, max)
+	assert.Equal(t, "hi", string(c.ExData()))
+	assert.True(t, c.IsBBoxDefined())
+
+	// an exported bbox stays exported, whether or not it's clipped
+	for _, tt := range [][2]string{
+		{`{"type":"LineString","bbox":[1,1,2,2],"coordinates":[[1,1],[2,2]]}`,
+			`{"type":"LineString","bbox":[1,1,2,2],"coordinates":[[1,1],[2,2]]}`},
+		{`{"type":"LineString","bbox":[-5,5,5,5],"coordinates":[[-5,5],[5,5]]}`,
+			`{"type":"LineString","bbox":[0,5,5,5],"coordinates":[[0,5],[5,5]]}`},
+		{`{"type":"Feature","bbox":[-5,5,5,5],"geometry":{"type":"LineString","coordinates":[[-5,5],[5,5]]},"properties":null}`,
+			`{"type":"Feature","bbox":[0,5,5,5],"geometry":{"type":"LineString","coordinates":[[0,5],[5,5]]},"properties":null}`},
+		{`{"type":"GeometryCollection","bbox":[-5,5,5,6],"geometries":[{"type":"Point","coordinates":[5,6]},{"type":"Point","coordinates":[-5,5]}]}`,
+			`{"type":"GeometryCollection","bbox":[5,6,5,6],"geometries":[{"type":"Point","coordinates":[5,6]}]}`},
+		// nothing is left
+		{`{"type":"LineString","bbox":[-5,-5,-1,-1],"coordinates":[[-5,-5],[-1,-1]]}`,
+			`{"type":"LineString","coordinates":[]}`},
+	} {
+		assert.Equal(t, tt[1], ParseJSON(tt[0]).ClipToBBox(bbox).JSON(), tt[0])
+	}
+
+	// foreign members are kept
+	o, _ = ParseJSONWithOptions(`{"type":"LineString","coordinates":[[-5,5],[5,5]],"title":"x"}`,
+		&ParseJSONOptions{ForeignMembers: true})
+	assert.Equal(t, `{"type":"LineString","coordinates":[[0,5],[5,5]],"title":"x"}`, o.ClipToBBox(bbox).JSON())
+}
+
+func TestClipToBBoxRandom(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 2000; i++ {
+		o := randTestObject(rnd)
+		min, max := o.Rect(nil)
+		x := min[0] + (max[0]-min[0])*rnd.Float64()
+		y := min[1] + (max[1]-min[1])*rnd.Float64()
+		bbox := BBox{Min: P(x-rnd.Float64()*10, y-rnd.Float64()*10), Max: P(x+rnd.Float64()*10, y+rnd.Float64()*10)}
+		c := o.ClipToBBox(bbox)
+		if err := Validate(c.Binary()); err != nil {
+			t.Fatalf("seed %d: %v\n%s", seed, err, o.JSON())
+		}
+		c.ForEachPosition(func(p Position) bool {
+			if !pointInsideRect(p, bbox) {
+				t.Fatalf("seed %d: %v outside of %v\n%s", seed, p, bbox, o.JSON())
+			}
+			return true
+		})
+	}
+}
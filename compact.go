@@ -0,0 +1,363 @@
+package geobin
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Compact geometries have the HEAD bit 2 set and store the coordinates as
+// zigzag varints. Each value is multiplied by the scale, rounded, and stored
+// as the difference from the same value of the previous position, starting
+// at zero. Only the Point through MultiPolygon types are compacted.
+// Collections and features compact their children.
+
+// maxCompactValue is the largest scaled value that can be compacted. It
+// keeps the difference between any two values from overflowing an int64.
+const maxCompactValue = 1 << 61
+
+// Compact returns a copy of the object with the coordinates compactly
+// encoded at the provided precision, such as 1e-7. Coordinates are rounded
+// to the nearest multiple of the precision and the bbox is recalculated,
+// unless it was user defined. The original object is returned if it cannot
+// be compacted, such as for strings and simple objects, for an invalid
+// precision, or for coordinates that are too large for the precision.
+//
+// Compacting is a storage format. Reading the coordinates of a compacted
+// geometry, such as with Geometry, the spatial predicates, or JSON, expands
+// a copy of it on every call. Objects that are read many times should be
+// expanded once with Expand.
+func (o Object) Compact(precision float64) Object {
+	if !(precision > 0) || !o.IsGeometry() || !o.valid() {
+		return o
+	}
+	scale := 1 / precision
+	if r := math.Round(scale); r >= 1 && math.Abs(scale-r) < r*1e-9 {
+		// 1e-7 becomes 1e7 and not 9999999.999999998
+		scale = r
+	}
+	if scale == 0 || math.IsInf(scale, 0) {
+		return o
+	}
+	if c, ok := compactObject(o.expandAll(), scale); ok {
+		return c
+	}
+	return o
+}
+
+// Expand returns a copy of the object with the compacted coordinates
+// converted back to float64s. The original object is returned if nothing
+// is compacted.
+func (o Object) Expand() Object {
+	if !o.IsGeometry() || !o.valid() {
+		return o
+	}
+	return o.expandAll()
+}
+
+// IsCompact returns true if the object, or any of its children, has
+// compacted coordinates.
+func (o Object) IsCompact() bool {
+	if !o.IsGeometry() || !o.valid() {
+		return false
+	}
+	return o.hasCompact()
+}
+
+// isCompact returns true if the head of a complex object has the compact bit.
+func (o Object) isCompact() bool {
+	if len(o.data) == 0 {
+		return false
+	}
+	tail := o.data[len(o.data)-1]
+	if tail&1 == 0 || tail>>3&1 == 0 {
+		return false
+	}
+	bboxSize := bboxSizeForTail(tail)
+	return len(o.data) > bboxSize && o.data[bboxSize]>>2&1 == 1
+}
+
+func (o Object) hasCompact() bool {
+	if o.isCompact() {
+		return true
+	}
+	var found bool
+	o.forEachChild(func(child Object) bool {
+		found = child.hasCompact()
+		return !found
+	})
+	return found
+}
+
+// geometryData returns the type, dims, and [GEOM] of a valid complex object
+// without expanding compacted coordinates.
+func (o Object) geometryData() Geometry {
+	tail := o.data[len(o.data)-1]
+	g := Geometry{Dims: 2}
+	if tail>>1&1 == 1 {
+		g.Dims = 3
+	}
+	data := o.parseComponents().data
+	g.Type = GeometryType(data[0] >> 4)
+	g.Data = data[1:]
+	if data[0]&1 == 1 {
+		// has members, skip over
+		sz, rest := readUint32(g.Data)
+		g.Data = rest[sz:]
+	}
+	return g
+}
+
+// forEachChild iterates over the geometry of a Feature or the children of a
+// collection.
+func (o Object) forEachChild(iter func(child Object) bool) {
+	if g := o.FeatureGeometry(); len(g.data) > 0 {
+		iter(g)
+		return
+	}
+	o.ForEachChild(func(_ int, child Object) bool {
+		return iter(child)
+	})
+}
+
+// geomDepth returns the number of nested counts in the coordinates of a
+// type, or -1 for types without coordinates.
+func geomDepth(typ GeometryType) int {
+	switch typ {
+	case Point:
+		return 0
+	case MultiPoint, LineString:
+		return 1
+	case MultiLineString, Polygon:
+		return 2
+	case MultiPolygon:
+		return 3
+	}
+	return -1
+}
+
+// putBBox writes a rect or point [BBOX] component.
+func putBBox(bbox []byte, dims int, min, max [3]float64) {
+	for i := 0; i < dims; i++ {
+		binary.LittleEndian.PutUint64(bbox[i*8:], math.Float64bits(min[i]))
+		if len(bbox) > dims*8 {
+			binary.LittleEndian.PutUint64(bbox[dims*8+i*8:], math.Float64bits(max[i]))
+		}
+	}
+}
+
+// mapChildren returns a copy of a valid Feature or collection with each
+// child replaced by fn. The bbox is recalculated from the children, unless
+// it was user defined.
+func (o Object) mapChildren(fn func(child Object) (Object, bool)) (Object, bool) {
+	c := o.parseComponents()
+	g := o.geometryData()
+	data := append([]byte{}, c.data[:len(c.data)-len(g.Data)]...)
+	if g.Type != Feature {
+		data = append(data, g.Data[:4]...)
+	}
+	min, max := baseMin, baseMax
+	ok := true
+	o.forEachChild(func(child Object) bool {
+		child, ok = fn(child)
+		if !ok {
+			return false
+		}
+		data = append(data, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(data[len(data)-4:], uint32(len(child.data)))
+		data = append(data, child.data...)
+		cmin, cmax := child.Rect(nil)
+		for i := 0; i < child.Dims(); i++ {
+			min[i] = math.Min(min[i], cmin[i])
+			max[i] = math.Max(max[i], cmax[i])
+		}
+		return true
+	})
+	if !ok {
+		return Object{}, false
+	}
+	if data[0]>>1&1 == 0 {
+		c.bbox = make([]byte, len(c.bbox))
+		putBBox(c.bbox, g.Dims, min, max)
+	}
+	c.data = data
+	return c.reconstructObject(), true
+}
+
+// compactObject compacts a valid object that has no compacted coordinates.
+func compactObject(o Object, scale float64) (Object, bool) {
+	if o.data[len(o.data)-1]>>3&1 == 0 {
+		return o, true // simple
+	}
+	g := o.geometryData()
+	depth := geomDepth(g.Type)
+	if depth == -1 {
+		return o.mapChildren(func(child Object) (Object, bool) {
+			return compactObject(child, scale)
+		})
+	}
+	w := compactWriter{scale: scale, dims: g.Dims, min: baseMin, max: baseMax}
+	w.out = make([]byte, 8, 8+len(g.Data)/2)
+	binary.LittleEndian.PutUint64(w.out, math.Float64bits(scale))
+	if _, ok := w.coords(g.Data, depth); !ok {
+		return Object{}, false
+	}
+	c := o.parseComponents()
+	data := append([]byte{}, c.data[:len(c.data)-len(g.Data)]...)
+	data[0] |= 1 << 2
+	c.data = append(data, w.out...)
+	if data[0]>>1&1 == 0 {
+		// recalculate the bbox from the rounded coordinates
+		c.bbox = make([]byte, len(c.bbox))
+		putBBox(c.bbox, g.Dims, w.min, w.max)
+	}
+	return c.reconstructObject(), true
+}
+
+// expandAll expands every compacted geometry in a valid object.
+func (o Object) expandAll() Object {
+	if o.isCompact() {
+		return o.expand()
+	}
+	if !o.hasCompact() {
+		return o
+	}
+	e, _ := o.mapChildren(func(child Object) (Object, bool) {
+		return child.expandAll(), true
+	})
+	return e
+}
+
+// expand expands a valid compacted geometry. The bbox is unchanged.
+func (o Object) expand() Object {
+	c := o.parseComponents()
+	g := o.geometryData()
+	scale, data := readFloat64(g.Data)
+	r := compactReader{data: data, scale: scale, dims: g.Dims}
+	out := append([]byte{}, c.data[:len(c.data)-len(g.Data)]...)
+	out[0] &^= 1 << 2
+	out, _ = r.expand(out, geomDepth(g.Type))
+	c.data = out
+	return c.reconstructObject()
+}
+
+type compactWriter struct {
+	out      []byte
+	scale    float64
+	dims     int
+	prev     [3]int64
+	min, max [3]float64 // bounds of the rounded values
+}
+
+// coords compacts a [UINT32][...] coordinates block, or a single position
+// when the depth is zero.
+func (w *compactWriter) coords(data []byte, depth int) ([]byte, bool) {
+	if depth == 0 {
+		var buf [binary.MaxVarintLen64]byte
+		for i := 0; i < w.dims; i++ {
+			var v float64
+			v, data = readFloat64(data)
+			q := math.Round(v * w.scale)
+			if !(math.Abs(q) < maxCompactValue) {
+				return nil, false
+			}
+			n := binary.PutVarint(buf[:], int64(q)-w.prev[i])
+			w.out = append(w.out, buf[:n]...)
+			w.prev[i] = int64(q)
+			v = q / w.scale
+			w.min[i] = math.Min(w.min[i], v)
+			w.max[i] = math.Max(w.max[i], v)
+		}
+		return data, true
+	}
+	w.out = append(w.out, data[:4]...)
+	n, data := readUint32(data)
+	for i := 0; i < n; i++ {
+		var ok bool
+		if data, ok = w.coords(data, depth-1); !ok {
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+type compactReader struct {
+	data  []byte
+	scale float64
+	dims  int
+	prev  [3]int64
+}
+
+// position reads the next position. Returns false if the data is malformed.
+func (r *compactReader) position() (Position, bool) {
+	var vals [3]float64
+	for i := 0; i < r.dims; i++ {
+		d, n := binary.Varint(r.data)
+		if n <= 0 {
+			return Position{}, false
+		}
+		r.data = r.data[n:]
+		r.prev[i] += d
+		vals[i] = float64(r.prev[i]) / r.scale
+	}
+	return Position{vals[0], vals[1], vals[2]}, true
+}
+
+// count reads the next count. Returns false if the data is malformed.
+func (r *compactReader) count(depth int) (int, bool) {
+	if len(r.data) < 4 {
+		return 0, false
+	}
+	n, data := readUint32(r.data)
+	// every position takes at least one byte per value, and every nested
+	// block at least four bytes for its count
+	min := r.dims
+	if depth > 1 {
+		min = 4
+	}
+	if n > len(data)/min {
+		return 0, false
+	}
+	r.data = data
+	return n, true
+}
+
+// expand appends the expanded [UINT32][...] coordinates block, or a single
+// position when the depth is zero. Returns false if the data is malformed.
+func (r *compactReader) expand(out []byte, depth int) ([]byte, bool) {
+	if depth == 0 {
+		p, ok := r.position()
+		if !ok {
+			return out, false
+		}
+		out = appendFloat64(out, p.X)
+		out = appendFloat64(out, p.Y)
+		if r.dims == 3 {
+			out = appendFloat64(out, p.Z)
+		}
+		return out, true
+	}
+	n, ok := r.count(depth)
+	if !ok {
+		return out, false
+	}
+	out = append(out, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(out[len(out)-4:], uint32(n))
+	for i := 0; i < n && ok; i++ {
+		out, ok = r.expand(out, depth-1)
+	}
+	return out, ok
+}
+
+// skip reads over a [UINT32][...] coordinates block, or a single position
+// when the depth is zero. Returns false if the data is malformed.
+func (r *compactReader) skip(depth int) bool {
+	if depth == 0 {
+		_, ok := r.position()
+		return ok
+	}
+	n, ok := r.count(depth)
+	for i := 0; i < n && ok; i++ {
+		ok = r.skip(depth - 1)
+	}
+	return ok
+}
@@ -0,0 +1,106 @@
+package geobin
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompact(t *testing.T) {
+	for _, js := range testValidateJSON {
+		o := ParseJSON(js).SetExData([]byte("extra"))
+		c := o.Compact(1e-7)
+		if o.PositionCount() > 1 {
+			// simple points are never compacted
+			assert.True(t, c.IsCompact(), js)
+		}
+		assert.False(t, o.IsCompact(), js)
+		assert.NoError(t, Validate(c.Binary()))
+		assert.Equal(t, o.JSON(), c.JSON())
+		assert.Equal(t, o.PositionCount(), c.PositionCount())
+		if geomDepth(o.GeometryType()) != -1 {
+			// the children of collections are not expanded
+			assert.Equal(t, o.Geometry(), c.Geometry())
+		}
+		assert.Equal(t, o.bridge(), c.bridge())
+		assert.Equal(t, o.WKT(), c.WKT())
+		assert.Equal(t, []byte("extra"), c.ExData())
+		assert.True(t, bytes.Equal(o.Binary(), c.Expand().Binary()), js)
+		assert.True(t, bytes.Equal(c.Binary(), c.Compact(1e-7).Binary()), js)
+	}
+}
+
+func TestCompactRounding(t *testing.T) {
+	o := ParseJSON(`{"type":"LineString","coordinates":[[1.2,2.6],[-3.3,4.1]]}`)
+	c := o.Compact(0.5)
+	assert.Equal(t, `{"type":"LineString","coordinates":[[1,2.5],[-3.5,4]]}`, c.JSON())
+	min, max := c.Rect(nil)
+	assert.Equal(t, [3]float64{-3.5, 2.5, 0}, min)
+	assert.Equal(t, [3]float64{1, 4, 0}, max)
+
+	// user defined bboxes are kept
+	o = ParseJSON(`{"type":"LineString","coordinates":[[1.2,2.6],[-3.3,4.1]],"bbox":[-10,-10,10,10]}`)
+	assert.Equal(t, `{"type":"LineString","bbox":[-10,-10,10,10],"coordinates":[[1,2.5],[-3.5,4]]}`,
+		o.Compact(0.5).JSON())
+
+	// features and collections recalculate from the children
+	o = ParseJSON(`{"type":"Feature","id":1,"geometry":{"type":"LineString","coordinates":[[1.2,2.6],[-3.3,4.1]]}}`)
+	c = o.Compact(0.5)
+	assert.Equal(t, `{"type":"Feature","geometry":{"type":"LineString","coordinates":[[1,2.5],[-3.5,4]]},"id":1}`, c.JSON())
+	min, max = c.Rect(nil)
+	assert.Equal(t, [3]float64{-3.5, 2.5, 0}, min)
+	assert.Equal(t, [3]float64{1, 4, 0}, max)
+	assert.Equal(t, c.JSON(), c.Expand().JSON())
+	assert.False(t, c.Expand().IsCompact())
+}
+
+func TestCompactUnchanged(t *testing.T) {
+	line := ParseJSON(`{"type":"LineString","coordinates":[[1,2],[3,4]]}`)
+	for _, o := range []Object{{}, MakeString("hello"), Make2DPoint(1, 2), Make3DRect(1, 2, 3, 4, 5, 6)} {
+		assert.Equal(t, o, o.Compact(1e-7))
+		assert.Equal(t, o, o.Expand())
+	}
+	for _, precision := range []float64{0, -1, math.NaN(), math.Inf(1), 1e-320} {
+		assert.Equal(t, line, line.Compact(precision))
+	}
+	huge := ParseJSON(`{"type":"LineString","coordinates":[[1,2],[1e300,4]]}`)
+	assert.Equal(t, huge, huge.Compact(1e-7))
+	assert.Equal(t, line, line.Expand())
+}
+
+func TestCompactSize(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var parts []string
+	x, y := -120.0, 35.0
+	for i := 0; i < 10000; i++ {
+		x += (rnd.Float64() - 0.5) * 0.001
+		y += (rnd.Float64() - 0.5) * 0.001
+		parts = append(parts, fmt.Sprintf("[%.7f,%.7f]", x, y))
+	}
+	parts = append(parts, parts[0])
+	o := ParseJSON(`{"type":"Polygon","coordinates":[[` + strings.Join(parts, ",") + `]]}`)
+	c := o.Compact(1e-7)
+	assert.True(t, len(c.Binary()) < len(o.Binary())/3,
+		"%d bytes compacted to %d bytes", len(o.Binary()), len(c.Binary()))
+	assert.Equal(t, o.JSON(), c.JSON())
+}
+
+func TestCompactPredicates(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 2000; i++ {
+		// integer coordinates are exact at a precision of one
+		a, b := randTestObject(rnd), randTestObject(rnd)
+		ca, cb := a.Compact(1), b.Compact(1)
+		if a.JSON() != ca.JSON() || a.Intersects(b) != ca.Intersects(cb) ||
+			a.Within(b) != ca.Within(cb) || a.bridge().Intersects(b.bridge()) != ca.bridge().Intersects(cb.bridge()) {
+			t.Fatalf("seed %d: mismatch\na: %s\nb: %s", seed, a.JSON(), b.JSON())
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package geobin
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+const rs = 0x1E // record separator for GeoJSON Text Sequences, RFC 8142
+
+// decoder states
+const (
+	decodeTop      = iota // between top-level values
+	decodeFeatures        // in the "features" array of a FeatureCollection
+	decodeAfter           // in the members after the "features" array
+)
+
+// Decoder reads GeoJSON objects from a stream. The stream can be a
+// FeatureCollection, a GeoJSON Text Sequence (RFC 8142), or newline
+// delimited GeoJSON. Only one feature at a time is held in memory.
+type Decoder struct {
+	r     *bufio.Reader
+	off   int // bytes read
+	state int
+	index int // index of the next feature
+	first bool
+	buf   []byte
+	err   error
+
+	// the "type" of the streamed FeatureCollection, and its offset, or the
+	// offset of the object when the "type" is missing
+	typ    string
+	typOff int
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Next returns the next object in the stream. That is each element of the
+// "features" of a FeatureCollection, and each top-level object otherwise.
+// A top-level "features" array is streamed unless the "type" that comes
+// before it is not a FeatureCollection. When the "type" comes after the
+// array, and is not a FeatureCollection, a *ParseError is returned after
+// the features. Returns io.EOF at the end of the stream. An object that is
+// not valid GeoJSON returns a *ParseError with the offset in the stream, and
+// the following objects can still be read. Malformed JSON stops the stream.
+func (d *Decoder) Next() (Object, error) {
+	if d.err != nil {
+		return Object{}, d.err
+	}
+	o, err := d.next()
+	if _, ok := err.(*ParseError); err != nil && !ok {
+		d.err = err
+	}
+	return o, err
+}
+
+func (d *Decoder) next() (Object, error) {
+	for {
+		switch d.state {
+		case decodeTop:
+			c, err := d.skipSpace(true)
+			if err != nil {
+				return Object{}, err
+			}
+			if c != '{' {
+				return Object{}, d.syntaxError()
+			}
+			start := d.off - 1
+			raw, err := d.readTop(start)
+			if err != nil {
+				return Object{}, err
+			}
+			if raw != nil {
+				return d.parse(raw, "", start)
+			}
+		case decodeFeatures:
+			c, err := d.skipSpace(false)
+			if err != nil {
+				return Object{}, d.eofError(err)
+			}
+			if c == ']' {
+				d.state = decodeAfter
+				continue
+			}
+			if !d.first {
+				if c != ',' {
+					return Object{}, d.syntaxError()
+				}
+				if c, err = d.skipSpace(false); err != nil {
+					return Object{}, d.eofError(err)
+				}
+			}
+			d.first = false
+			start := d.off - 1
+			raw, err := d.readValue(c, d.buf[:0])
+			if err != nil {
+				return Object{}, err
+			}
+			d.buf = raw
+			d.index++
+			return d.parse(raw, "features."+strconv.Itoa(d.index-1), start)
+		case decodeAfter:
+			c, err := d.skipSpace(false)
+			if err != nil {
+				return Object{}, d.eofError(err)
+			}
+			switch c {
+			case '}':
+				d.state = decodeTop
+				if d.typ != "FeatureCollection" {
+					return Object{}, &ParseError{Path: "type", Offset: d.typOff,
+						Reason: errInvalidType}
+				}
+			case ',':
+				if c, err = d.skipSpace(false); err != nil {
+					return Object{}, d.eofError(err)
+				}
+				var key string
+				if _, key, c, err = d.readKey(c, nil); err != nil {
+					return Object{}, err
+				}
+				off := d.off - 1
+				raw, err := d.readValue(c, d.buf[:0])
+				if err != nil {
+					return Object{}, err
+				}
+				d.buf = raw
+				if key == "type" {
+					d.typ, d.typOff = gjson.ParseBytes(raw).String(), off
+				}
+			default:
+				return Object{}, d.syntaxError()
+			}
+		}
+	}
+}
+
+// readTop reads the members of a top-level object that starts at the
+// offset in the stream. The whitespace is kept, so the offsets in the raw
+// object match the stream. Returns nil when the "features" array is found,
+// and the "type" is a FeatureCollection or is not known yet, in which case
+// the decoder moves into the array.
+func (d *Decoder) readTop(start int) ([]byte, error) {
+	raw := append(d.buf[:0], '{')
+	d.buf = raw
+	typ, typOff := "", start
+	var hasType, members bool
+	for {
+		var c byte
+		var err error
+		if raw, c, err = d.readSpace(raw); err != nil {
+			return nil, d.eofError(err)
+		}
+		if c == '}' {
+			raw = append(raw, '}')
+			d.buf = raw
+			return raw, nil
+		}
+		if members {
+			if c != ',' {
+				return nil, d.syntaxError()
+			}
+			raw = append(raw, ',')
+			if raw, c, err = d.readSpace(raw); err != nil {
+				return nil, d.eofError(err)
+			}
+		}
+		members = true
+		var key string
+		if raw, key, c, err = d.readKey(c, raw); err != nil {
+			return nil, err
+		}
+		if key == "features" && c == '[' && (!hasType || typ == "FeatureCollection") {
+			d.state = decodeFeatures
+			d.first = true
+			d.index = 0
+			d.typ, d.typOff = typ, typOff
+			return nil, nil
+		}
+		mark := len(raw)
+		if raw, err = d.readValue(c, raw); err != nil {
+			return nil, err
+		}
+		if key == "type" {
+			typ, typOff, hasType = gjson.ParseBytes(raw[mark:]).String(), start+mark, true
+		}
+	}
+}
+
+// readKey reads a "key": member name that starts with c, and the whitespace
+// that follows, and appends them to raw. Returns the first byte of the
+// value.
+func (d *Decoder) readKey(c byte, raw []byte) ([]byte, string, byte, error) {
+	if c != '"' {
+		return raw, "", 0, d.syntaxError()
+	}
+	mark := len(raw)
+	raw, err := d.readValue(c, raw)
+	if err != nil {
+		return raw, "", 0, err
+	}
+	key := gjson.ParseBytes(raw[mark:]).String()
+	if raw, c, err = d.readSpace(raw); err != nil {
+		return raw, "", 0, d.eofError(err)
+	}
+	if c != ':' {
+		return raw, "", 0, d.syntaxError()
+	}
+	raw = append(raw, ':')
+	if raw, c, err = d.readSpace(raw); err != nil {
+		return raw, "", 0, d.eofError(err)
+	}
+	return raw, key, c, nil
+}
+
+// readValue reads the rest of a JSON value that starts with c.
+func (d *Decoder) readValue(c byte, raw []byte) ([]byte, error) {
+	raw = append(raw, c)
+	switch c {
+	case '{', '[':
+		depth := 1
+		for depth > 0 {
+			c, err := d.readByte()
+			if err != nil {
+				return raw, d.eofError(err)
+			}
+			switch c {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			case '"':
+				raw, err = d.readValue(c, raw)
+				if err != nil {
+					return raw, err
+				}
+				continue
+			}
+			raw = append(raw, c)
+		}
+	case '"':
+		for {
+			c, err := d.readByte()
+			if err != nil {
+				return raw, d.eofError(err)
+			}
+			raw = append(raw, c)
+			if c == '"' {
+				break
+			}
+			if c == '\\' {
+				if c, err = d.readByte(); err != nil {
+					return raw, d.eofError(err)
+				}
+				raw = append(raw, c)
+			}
+		}
+	default:
+		for {
+			c, err := d.readByte()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return raw, err
+			}
+			if c <= ' ' || c == ',' || c == ']' || c == '}' || c == rs {
+				d.r.UnreadByte()
+				d.off--
+				break
+			}
+			raw = append(raw, c)
+		}
+	}
+	return raw, nil
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	c, err := d.r.ReadByte()
+	if err == nil {
+		d.off++
+	}
+	return c, err
+}
+
+// readSpace appends the whitespace to raw and returns the next byte that is
+// not whitespace.
+func (d *Decoder) readSpace(raw []byte) ([]byte, byte, error) {
+	for {
+		c, err := d.readByte()
+		if err != nil {
+			return raw, 0, err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			raw = append(raw, c)
+		default:
+			return raw, c, nil
+		}
+	}
+}
+
+// skipSpace returns the next byte that is not whitespace. The record
+// separator is also skipped when rs is true.
+func (d *Decoder) skipSpace(skipRS bool) (byte, error) {
+	for {
+		c, err := d.readByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+		case rs:
+			if !skipRS {
+				return c, nil
+			}
+		default:
+			return c, nil
+		}
+	}
+}
+
+// parse parses a raw object that starts at the offset in the stream.
+func (d *Decoder) parse(raw []byte, path string, offset int) (Object, error) {
+	json := string(raw)
+	if !gjson.Valid(json) {
+		return Object{}, d.syntaxError()
+	}
+	o, err := objectFromJSON(json, &ParseJSONOptions{})
+	if err != nil {
+		e := locateError(json, err).(*ParseError)
+		return Object{}, &ParseError{
+			Path:   joinPath(path, e.Path),
+			Offset: offset + e.Offset,
+			Reason: e.Reason,
+		}
+	}
+	return o, nil
+}
+
+// syntaxError returns an error for malformed JSON at the current offset.
+// It stops the stream.
+func (d *Decoder) syntaxError() error {
+	d.err = &ParseError{Offset: d.off, Reason: errInvalidJSON}
+	return d.err
+}
+
+// eofError returns an error for a stream that ends in the middle of a
+// value.
+func (d *Decoder) eofError(err error) error {
+	if err == io.EOF {
+		return d.syntaxError()
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package geobin
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func testDecodeAll(t *testing.T, input string) ([]string, error) {
+	t.Helper()
+	d := NewDecoder(strings.NewReader(input))
+	var out []string
+	for {
+		o, err := d.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, o.JSON())
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	f1 := `{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":"[{\"}"}}`
+	f2 := `{"type":"Feature","id":2,"geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]}}`
+	var tests = []string{
+		`{"type":"FeatureCollection","features":[` + f1 + `,` + f2 + `]}`,
+		` { "features" : [ ` + f1 + ` , ` + f2 + ` ] , "type" : "FeatureCollection" , "x" : [true,null,{"features":[1]}] } `,
+		`{"bbox":[1,2,3,4],"type":"FeatureCollection","features":[` + f1 + `,` + f2 + `],"name":"x"}`,
+		f1 + "\n" + f2 + "\n",
+		f1 + f2,
+		"\x1e" + f1 + "\n\x1e" + f2 + "\n",
+		`{"type":"FeatureCollection","features":[` + f1 + `]}` + "\n" + f2,
+	}
+	for _, input := range tests {
+		out, err := testDecodeAll(t, input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, []string{ParseJSON(f1).JSON(), ParseJSON(f2).JSON()}, out, input)
+		}
+	}
+	out, err := testDecodeAll(t, `{"type":"FeatureCollection","features":[]}`)
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+	out, err = testDecodeAll(t, " \n ")
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+
+	// only a FeatureCollection has its features streamed
+	for _, input := range []string{
+		`{"type":"Feature","features":[` + f1 + `],"geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":1}}`,
+		`{"type":"GeometryCollection","features":[` + f1 + `],"geometries":[{"type":"Point","coordinates":[1,2]}]}`,
+	} {
+		out, err := testDecodeAll(t, input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, []string{ParseJSON(input).JSON()}, out, input)
+		}
+	}
+	// the "type" after the features must be a FeatureCollection
+	for _, input := range []string{
+		`{"features":[` + f1 + `],"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":1}}`,
+		`{"features":[` + f1 + `], "type" : "GeometryCollection","geometries":[]}`,
+		`{"features":[` + f1 + `]}`,
+	} {
+		d := NewDecoder(strings.NewReader(input + "\n" + f2))
+		o, err := d.Next()
+		assert.NoError(t, err, input)
+		assert.Equal(t, ParseJSON(f1).JSON(), o.JSON(), input)
+		_, err = d.Next()
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), input) {
+			assert.True(t, errors.Is(err, errInvalidType), input)
+			assert.Equal(t, "type", perr.Path)
+			if typ := gjson.Get(input, "type"); typ.Exists() {
+				assert.Equal(t, typ.Index, perr.Offset, input)
+			} else {
+				assert.Equal(t, 0, perr.Offset)
+			}
+		}
+		o, err = d.Next()
+		assert.NoError(t, err, input)
+		assert.Equal(t, ParseJSON(f2).JSON(), o.JSON(), input)
+	}
+	out, err = testDecodeAll(t, `{"features":[],"type":"FeatureCollection"}`+"\n"+f1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ParseJSON(f1).JSON()}, out)
+}
+
+func TestDecoderErrors(t *testing.T) {
+	// invalid features are reported and skipped
+	input := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}},` +
+		`{"type":"Feature","geometry":{"type":"Point"}},` +
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[3,4]}}]}`
+	d := NewDecoder(strings.NewReader(input))
+	_, err := d.Next()
+	assert.NoError(t, err)
+	_, err = d.Next()
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.True(t, errors.Is(err, errInvalidCoordinates))
+		assert.Equal(t, "features.1.geometry.coordinates", perr.Path)
+		assert.True(t, strings.HasPrefix(input[perr.Offset:], `{"type":"Point"}`), input[perr.Offset:])
+	}
+	o, err := d.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, Position{3, 4, 0}, o.FeatureGeometry().Position())
+	_, err = d.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// the offsets of pretty-printed objects
+	for _, input := range []string{
+		`{ "type" : "Feature" ,   "geometry" :   {"type":"Point"} }`,
+		"{\n  \"type\": \"Feature\",\n  \"geometry\": {\n    \"type\": \"Point\"\n  }\n}",
+		` { "type" : "FeatureCollection" , "features" : [ {"type":"Feature", "geometry": {"type":"Point"}} ] }`,
+		` { "features" : [ {"type":"Feature",` + "\n" + `"geometry": {"type":"Point"}} ], "type" : "FeatureCollection" }`,
+	} {
+		d := NewDecoder(strings.NewReader(input))
+		_, err := d.Next()
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), input) {
+			assert.True(t, errors.Is(err, errInvalidCoordinates), input)
+			assert.True(t, strings.HasSuffix(perr.Path, "geometry.coordinates"), perr.Path)
+			assert.True(t, strings.HasPrefix(input[perr.Offset:], `{`), "%s: %d", input, perr.Offset)
+			assert.Equal(t, "Point", gjson.Get(input[perr.Offset:], "type").String(), input)
+		}
+		_, err = d.Next()
+		assert.Equal(t, io.EOF, err, input)
+	}
+
+	// malformed json stops the stream
+	for _, input := range []string{
+		`[`, `{`, `{"type":"FeatureCollection","features":[{"a":1}`,
+		`{"type":"FeatureCollection","features":[{"a":1}}`,
+		`{"type":"FeatureCollection","features":[{"a":1} {"a":2}]}`,
+		`{"type":"FeatureCollection","features":[]`, `{"a" 1}`, `{"a":1 "b":2}`,
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},}`,
+	} {
+		d := NewDecoder(strings.NewReader(input))
+		var err error
+		for i := 0; i < 5; i++ {
+			if _, err = d.Next(); err == io.EOF || errors.Is(err, errInvalidJSON) {
+				break
+			}
+		}
+		assert.True(t, errors.Is(err, errInvalidJSON), "%s: %v", input, err)
+		_, err2 := d.Next()
+		assert.Equal(t, err, err2)
+	}
+}
@@ -0,0 +1,282 @@
+package geobin
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/tidwall/tile38/geojson/geo"
+)
+
+// DistanceTo returns the geodesic distance, in meters, from the position to
+// the nearest part of the object. Segments are great circle arcs. Positions
+// on a line, or inside of a polygon, are zero. Returns +Inf for an object
+// that has no positions.
+func (o Object) DistanceTo(p Position) float64 {
+	dist := math.Inf(1)
+	if !o.IsGeometry() || !o.valid() {
+		return dist
+	}
+	o.ForEachPart(func(part Geometry) bool {
+		dist = math.Min(dist, partDistance(part, p))
+		return dist > 0
+	})
+	return dist
+}
+
+// partDistance returns the distance to a single part.
+func partDistance(part Geometry, p Position) float64 {
+	dist := math.Inf(1)
+	switch part.Type {
+	case Point:
+		part.ForEachPosition(func(q Position) bool {
+			dist = geo.DistanceTo(p.Y, p.X, q.Y, q.X)
+			return false
+		})
+	case LineString:
+		part.ForEachRing(func(r RingReader) bool {
+			dist = lineDistance(r, p, dist)
+			return true
+		})
+	default:
+		// polygons, including simple rects
+		var inside bool
+		forEachPolygonRing(part, func(i int, r RingReader) bool {
+			in := ringContains(r, p)
+			if i == 0 {
+				inside = in
+			} else if in {
+				inside = false
+			}
+			dist = lineDistance(r, p, dist)
+			return dist > 0
+		})
+		if inside {
+			return 0
+		}
+	}
+	return dist
+}
+
+// lineDistance returns the smaller of dist and the distance to the line.
+func lineDistance(r RingReader, p Position, dist float64) float64 {
+	if r.Len() == 1 {
+		q := r.At(0)
+		return math.Min(dist, geo.DistanceTo(p.Y, p.X, q.Y, q.X))
+	}
+	for i := 1; i < r.Len() && dist > 0; i++ {
+		a, b := r.At(i-1), r.At(i)
+		if _, on := raycast(p, a, b); on {
+			return 0
+		}
+		dist = math.Min(dist, arcDistance(p, a, b))
+	}
+	return dist
+}
+
+// ringContains returns true if the position is inside of, or on the edge
+// of, a ring.
+func ringContains(r RingReader, p Position) bool {
+	var in bool
+	for i := 0; i < r.Len(); i++ {
+		rin, ron := raycast(p, r.At(i), r.At((i+1)%r.Len()))
+		if ron {
+			return true
+		}
+		if rin {
+			in = !in
+		}
+	}
+	return in
+}
+
+// objectWraps returns true if the object has an arc between positions that
+// are more than 180 degrees of longitude apart, which crosses the
+// antimeridian.
+func objectWraps(o Object) bool {
+	var wraps bool
+	ring := func(_ int, r RingReader) bool {
+		for i := 1; i < r.Len() && !wraps; i++ {
+			wraps = math.Abs(r.At(i).X-r.At(i-1).X) > 180
+		}
+		return !wraps
+	}
+	o.ForEachPart(func(part Geometry) bool {
+		switch part.Type {
+		case Point:
+		case LineString:
+			part.ForEachRing(func(r RingReader) bool {
+				return ring(0, r)
+			})
+		default:
+			forEachPolygonRing(part, ring)
+		}
+		return !wraps
+	})
+	return wraps
+}
+
+// unitVector returns the position on a unit sphere.
+func unitVector(p Position) [3]float64 {
+	lat, lon := toRadians(p.Y), toRadians(p.X)
+	return [3]float64{
+		math.Cos(lat) * math.Cos(lon),
+		math.Cos(lat) * math.Sin(lon),
+		math.Sin(lat),
+	}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+// arcDistance returns the geodesic distance, in meters, from p to the great
+// circle arc ab.
+func arcDistance(p, a, b Position) float64 {
+	dist := math.Min(
+		geo.DistanceTo(p.Y, p.X, a.Y, a.X),
+		geo.DistanceTo(p.Y, p.X, b.Y, b.X),
+	)
+	va, vb, vp := unitVector(a), unitVector(b), unitVector(p)
+	n := cross(va, vb)
+	size := math.Sqrt(dot(n, n))
+	if size < 1e-15 {
+		return dist
+	}
+	n = [3]float64{n[0] / size, n[1] / size, n[2] / size}
+	// the projection of p onto the great circle is on the arc when it is
+	// between a and b
+	s := dot(vp, n)
+	c := [3]float64{vp[0] - s*n[0], vp[1] - s*n[1], vp[2] - s*n[2]}
+	if dot(cross(va, c), n) >= 0 && dot(cross(c, vb), n) >= 0 {
+		dist = math.Min(dist, math.Asin(math.Min(1, math.Abs(s)))*earthRadius)
+	}
+	return dist
+}
+
+// rectDistance returns the geodesic distance, in meters, from p to the
+// nearest point of a longitude and latitude rect. This is a lower bound of
+// the distance to the positions inside of the rect, and to the arcs between
+// them.
+func rectDistance(r indexRect, p Position) float64 {
+	if r.wraps {
+		// the arcs that cross the antimeridian are outside of the rect
+		return 0
+	}
+	// the arcs bulge toward the poles, up to the middle of an arc between
+	// the corners
+	if w := r.max[0] - r.min[0]; w >= 180 {
+		r.min[1], r.max[1] = -90, 90
+	} else {
+		c := math.Cos(toRadians(w / 2))
+		if r.max[1] > 0 {
+			r.max[1] = math.Atan(math.Tan(toRadians(r.max[1]))/c) * 180 / math.Pi
+		}
+		if r.min[1] < 0 {
+			r.min[1] = math.Atan(math.Tan(toRadians(r.min[1]))/c) * 180 / math.Pi
+		}
+	}
+	if p.X >= r.min[0] && p.X <= r.max[0] {
+		lat := math.Max(r.min[1], math.Min(r.max[1], p.Y))
+		return geo.DistanceTo(p.Y, p.X, lat, p.X)
+	}
+	// the nearest point is on the west or east edge, which are meridians
+	// that are split to be shorter than a half circle
+	mid := (r.min[1] + r.max[1]) / 2
+	dist := math.Inf(1)
+	for _, x := range [2]float64{r.min[0], r.max[0]} {
+		lo, hi := Position{X: x, Y: r.min[1]}, Position{X: x, Y: r.max[1]}
+		m := Position{X: x, Y: mid}
+		dist = math.Min(dist, math.Min(arcDistance(p, lo, m), arcDistance(p, m, hi)))
+	}
+	return dist
+}
+
+type knnEntry struct {
+	dist  float64
+	exact bool       // dist is the distance to the object
+	node  *indexNode // a node of an Index, or nil for an object
+	id    string
+	index int
+	obj   Object
+}
+
+// knnQueue is a min heap of entries ordered by their distance.
+type knnQueue []knnEntry
+
+func (q knnQueue) Len() int           { return len(q) }
+func (q knnQueue) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q knnQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *knnQueue) Push(x interface{}) {
+	*q = append(*q, x.(knnEntry))
+}
+func (q *knnQueue) Pop() interface{} {
+	e := (*q)[len(*q)-1]
+	*q = (*q)[:len(*q)-1]
+	return e
+}
+
+// nearest pops the entries in order of distance. Nodes are expanded and
+// objects are refined from the bbox lower bound to the exact distance
+// before they are yielded.
+func (q *knnQueue) nearest(p Position, iter func(e knnEntry) bool) {
+	for q.Len() > 0 {
+		e := heap.Pop(q).(knnEntry)
+		switch {
+		case e.node != nil && e.node.leaf:
+			for _, item := range e.node.items {
+				heap.Push(q, knnEntry{dist: rectDistance(item.rect, p),
+					id: item.id, obj: item.obj})
+			}
+		case e.node != nil:
+			for _, child := range e.node.children {
+				heap.Push(q, knnEntry{dist: rectDistance(child.rect, p), node: child})
+			}
+		case e.exact:
+			if !iter(e) {
+				return
+			}
+		default:
+			e.dist, e.exact = e.obj.DistanceTo(p), true
+			heap.Push(q, e)
+		}
+	}
+}
+
+// Nearest iterates over the objects in order of their distance, in meters,
+// from the position. See Object.DistanceTo. Return false from iter to stop,
+// such as after the first k objects.
+func (idx *Index) Nearest(p Position, iter func(id string, o Object, dist float64) bool) {
+	if idx.root == nil {
+		return
+	}
+	q := knnQueue{{dist: rectDistance(idx.root.rect, p), node: idx.root}}
+	q.nearest(p, func(e knnEntry) bool {
+		return iter(e.id, e.obj, e.dist)
+	})
+}
+
+// NearestChildren iterates over the children of a collection in order of
+// their distance, in meters, from the position. See Object.DistanceTo.
+// Children that have no positions are skipped. Return false from iter to
+// stop, such as after the first k children.
+func (o Object) NearestChildren(p Position, iter func(index int, child Object, dist float64) bool) {
+	var q knnQueue
+	o.ForEachChild(func(index int, child Object) bool {
+		if rect, ok := objectRect(child); ok {
+			q = append(q, knnEntry{dist: rectDistance(rect, p), index: index, obj: child})
+		}
+		return true
+	})
+	heap.Init(&q)
+	q.nearest(p, func(e knnEntry) bool {
+		return iter(e.index, e.obj, e.dist)
+	})
+}
@@ -0,0 +1,183 @@
+package geobin
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceTo(t *testing.T) {
+	deg := P(0, 0).DistanceTo(P(0, 1))
+	poly := `{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[4,4],[6,4],[6,6],[4,6],[4,4]]]}`
+	var tests = []struct {
+		json   string
+		p      Position
+		expect float64
+	}{
+		{`{"type":"Point","coordinates":[1,2]}`, P(1, 2), 0},
+		{`{"type":"Point","coordinates":[1,2]}`, P(1, 3), deg},
+		{`{"type":"MultiPoint","coordinates":[[1,5],[1,2]]}`, P(1, 3), deg},
+		// the nearest point of the equator is straight south
+		{`{"type":"LineString","coordinates":[[0,0],[10,0]]}`, P(5, 1), deg},
+		{`{"type":"LineString","coordinates":[[0,0],[10,0]]}`, P(5, 0), 0},
+		{`{"type":"LineString","coordinates":[[0,0],[10,0]]}`, P(12, 0), 2 * deg},
+		{`{"type":"LineString","coordinates":[[0,0],[10,0]]}`, P(-1, 1), P(-1, 1).DistanceTo(P(0, 0))},
+		{`{"type":"MultiLineString","coordinates":[[[0,5],[10,5]],[[0,0],[10,0]]]}`, P(5, 1), deg},
+		// polygons
+		{poly, P(2, 2), 0},
+		{poly, P(10, 5), 0},
+		{poly, P(5, 4.5), arcDistance(P(5, 4.5), P(4, 4), P(6, 4))},
+		{poly, P(4, 5), 0},
+		{poly, P(5, -1), deg},
+		{`{"type":"MultiPolygon","coordinates":[[[[20,20],[30,20],[30,30],[20,20]]],[[[0,0],[10,0],[10,10],[0,0]]]]}`, P(8, 2), 0},
+		// collections
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[50,50]},{"type":"LineString","coordinates":[[0,0],[10,0]]}]}`,
+			P(5, -1), deg},
+		{`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":null}`, P(1, 3), deg},
+		{`{"type":"LineString","coordinates":[]}`, P(1, 3), math.Inf(1)},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.expect, ParseJSON(tt.json).DistanceTo(tt.p), 1e-6, "%s %v", tt.json, tt.p)
+	}
+	assert.Equal(t, 0.0, Make2DRect(0, 0, 10, 10).DistanceTo(P(5, 5)))
+	// the north edge is a great circle that bulges to the north
+	top := arcDistance(P(5, 11), P(0, 10), P(10, 10))
+	assert.Less(t, top, deg)
+	assert.InDelta(t, top, Make2DRect(0, 0, 10, 10).DistanceTo(P(5, 11)), 1e-6)
+	assert.InDelta(t, top, Make3DRect(0, 0, 0, 10, 10, 10).DistanceTo(P(5, 11)), 1e-6)
+	assert.True(t, math.IsInf(MakeString("hello").DistanceTo(P(0, 0)), 1))
+}
+
+func TestArcDistance(t *testing.T) {
+	// compare with positions sampled along the arc
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		a := P(rnd.Float64()*40-20, rnd.Float64()*40-20)
+		b := P(rnd.Float64()*40-20, rnd.Float64()*40-20)
+		p := P(rnd.Float64()*60-30, rnd.Float64()*60-30)
+		va, vb := unitVector(a), unitVector(b)
+		sampled := math.Inf(1)
+		for j := 0; j <= 1000; j++ {
+			f := float64(j) / 1000
+			v := [3]float64{va[0]*(1-f) + vb[0]*f, va[1]*(1-f) + vb[1]*f, va[2]*(1-f) + vb[2]*f}
+			q := P(math.Atan2(v[1], v[0])*180/math.Pi,
+				math.Atan2(v[2], math.Hypot(v[0], v[1]))*180/math.Pi)
+			sampled = math.Min(sampled, p.DistanceTo(q))
+		}
+		dist := arcDistance(p, a, b)
+		assert.LessOrEqual(t, dist, sampled+1e-6)
+		assert.InDelta(t, sampled, dist, 1000)
+	}
+}
+
+func TestRectDistance(t *testing.T) {
+	// a lower bound of every position in the rect
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		x, y := rnd.Float64()*300-150, rnd.Float64()*140-70
+		r := indexRect{min: [2]float64{x, y}, max: [2]float64{x + rnd.Float64()*30, y + rnd.Float64()*20}}
+		p := P(rnd.Float64()*360-180, rnd.Float64()*180-90)
+		dist := rectDistance(r, p)
+		for j := 0; j < 100; j++ {
+			q := P(r.min[0]+rnd.Float64()*(r.max[0]-r.min[0]), r.min[1]+rnd.Float64()*(r.max[1]-r.min[1]))
+			assert.LessOrEqual(t, dist, p.DistanceTo(q)+1e-6)
+		}
+	}
+	full := indexRect{min: [2]float64{10, -90}, max: [2]float64{20, 90}}
+	assert.InDelta(t, P(0, 0).DistanceTo(P(10, 0)), rectDistance(full, P(0, 0)), 1e-6)
+	assert.Equal(t, 0.0, rectDistance(full, P(15, 0)))
+	// the arc between the north corners
+	r := indexRect{min: [2]float64{0, 0}, max: [2]float64{60, 60}}
+	assert.LessOrEqual(t, rectDistance(r, P(30, 62)), arcDistance(P(30, 62), P(0, 60), P(60, 60)))
+	r = indexRect{min: [2]float64{0, -60}, max: [2]float64{60, 0}}
+	assert.LessOrEqual(t, rectDistance(r, P(30, -62)), arcDistance(P(30, -62), P(0, -60), P(60, -60)))
+}
+
+func TestNearest(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	var idx Index
+	var children []string
+	objs := make(map[string]Object)
+	for i := 0; i < 500; i++ {
+		x, y := rnd.Float64()*40-20, rnd.Float64()*40-20
+		var json string
+		switch rnd.Intn(3) {
+		case 0:
+			json = `{"type":"Point","coordinates":[` + ftoa(x) + `,` + ftoa(y) + `]}`
+		case 1:
+			json = `{"type":"LineString","coordinates":[[` + ftoa(x) + `,` + ftoa(y) + `],[` +
+				ftoa(x+rnd.Float64()*4) + `,` + ftoa(y+rnd.Float64()*4) + `]]}`
+		default:
+			w, h := ftoa(x+rnd.Float64()*3), ftoa(y+rnd.Float64()*3)
+			json = `{"type":"Polygon","coordinates":[[[` + ftoa(x) + `,` + ftoa(y) + `],[` + w + `,` + ftoa(y) +
+				`],[` + w + `,` + h + `],[` + ftoa(x) + `,` + h + `],[` + ftoa(x) + `,` + ftoa(y) + `]]]}`
+		}
+		id := strconv.Itoa(i)
+		objs[id] = ParseJSON(json)
+		idx.Insert(id, objs[id])
+		children = append(children, json)
+	}
+	coll := ParseJSON(`{"type":"GeometryCollection","geometries":[` + joinJSON(children) + `]}`)
+	p := P(rnd.Float64()*40-20, rnd.Float64()*40-20)
+	var expect []float64
+	for _, o := range objs {
+		expect = append(expect, o.DistanceTo(p))
+	}
+	sort.Float64s(expect)
+
+	var dists []float64
+	idx.Nearest(p, func(id string, o Object, dist float64) bool {
+		assert.Equal(t, objs[id].DistanceTo(p), dist)
+		dists = append(dists, dist)
+		return len(dists) < 10
+	})
+	assert.Equal(t, expect[:10], dists, "seed %d", seed)
+
+	dists = nil
+	coll.NearestChildren(p, func(index int, child Object, dist float64) bool {
+		assert.Equal(t, objs[strconv.Itoa(index)].DistanceTo(p), dist)
+		dists = append(dists, dist)
+		return true
+	})
+	assert.Equal(t, expect, dists, "seed %d", seed)
+
+	// an arc that crosses the antimeridian
+	var wrap Index
+	wrap.Insert("a", ParseJSON(`{"type":"LineString","coordinates":[[-170,0],[170,0]]}`))
+	wrap.Insert("b", Make2DPoint(175, 5))
+	var ids []string
+	wrap.Nearest(P(180, 0), func(id string, o Object, dist float64) bool {
+		ids = append(ids, id)
+		return true
+	})
+	assert.Equal(t, []string{"a", "b"}, ids)
+	assert.Equal(t, 0.0, ParseJSON(`{"type":"LineString","coordinates":[[-170,0],[170,0]]}`).DistanceTo(P(180, 0)))
+
+	// empty
+	var empty Index
+	empty.Nearest(p, func(id string, o Object, dist float64) bool {
+		t.Fatal("unexpected")
+		return true
+	})
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func joinJSON(parts []string) string {
+	var s string
+	for i, part := range parts {
+		if i > 0 {
+			s += ","
+		}
+		s += part
+	}
+	return s
+}
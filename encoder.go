@@ -0,0 +1,161 @@
+package geobin
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+var errEncoderClosed = errors.New("encoder closed")
+
+// EncodeFormat is the output format of an Encoder.
+type EncodeFormat byte
+
+const (
+	// FeatureCollectionFormat writes a single FeatureCollection.
+	FeatureCollectionFormat EncodeFormat = iota
+	// TextSequenceFormat writes a GeoJSON Text Sequence, RFC 8142.
+	TextSequenceFormat
+	// NDJSONFormat writes newline delimited GeoJSON.
+	NDJSONFormat
+)
+
+// Encoder writes GeoJSON objects to a stream, one at a time.
+type Encoder struct {
+	w        io.Writer
+	format   EncodeFormat
+	buf      []byte
+	count    int
+	bbox     bool
+	dims     int
+	min, max [3]float64
+	closed   bool
+	err      error
+}
+
+// NewEncoder returns a new encoder that writes to w in the format.
+func NewEncoder(w io.Writer, format EncodeFormat) *Encoder {
+	return &Encoder{w: w, format: format, min: baseMin, max: baseMax}
+}
+
+// SetRunningBBox sets whether the bbox of all of the encoded objects is
+// written. For the FeatureCollectionFormat it is written as a trailing
+// "bbox" member by Close, unless none of the objects had positions. It is
+// not written for the other formats.
+func (e *Encoder) SetRunningBBox(enabled bool) {
+	e.bbox = enabled
+}
+
+// Encode writes the object. For the FeatureCollectionFormat, geometries are
+// wrapped in a Feature and the features of a FeatureCollection are written
+// individually. Returns an error if the object is not a geometry.
+func (e *Encoder) Encode(o Object) error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.closed {
+		return errEncoderClosed
+	}
+	if !o.IsGeometry() || !o.valid() {
+		return errInvalidGeometry
+	}
+	e.buf = e.buf[:0]
+	switch e.format {
+	case FeatureCollectionFormat:
+		if o.GeometryType() == FeatureCollection {
+			o.ForEachChild(func(_ int, child Object) bool {
+				e.appendFeature(child)
+				return true
+			})
+		} else {
+			e.appendFeature(o)
+		}
+	case TextSequenceFormat:
+		e.buf = append(e.buf, rs)
+		e.buf = append(o.AppendJSON(e.buf), '\n')
+		e.extend(o)
+	default:
+		e.buf = append(o.AppendJSON(e.buf), '\n')
+		e.extend(o)
+	}
+	return e.write()
+}
+
+// appendFeature appends an element of the "features" array.
+func (e *Encoder) appendFeature(o Object) {
+	if e.count == 0 {
+		e.buf = append(e.buf, `{"type":"FeatureCollection","features":[`...)
+	} else {
+		e.buf = append(e.buf, ',')
+	}
+	if o.GeometryType() == Feature {
+		e.buf = o.AppendJSON(e.buf)
+	} else {
+		e.buf = append(e.buf, `{"type":"Feature","geometry":`...)
+		e.buf = o.AppendJSON(e.buf)
+		e.buf = append(e.buf, `,"properties":null}`...)
+	}
+	e.extend(o)
+}
+
+// extend adds an encoded object to the running bbox. Objects without
+// positions are not added.
+func (e *Encoder) extend(o Object) {
+	e.count++
+	min, max := o.Rect(nil)
+	if !(min[0] <= max[0]) {
+		return
+	}
+	dims := o.Dims()
+	if dims > e.dims {
+		e.dims = dims
+	}
+	for i := 0; i < dims; i++ {
+		e.min[i] = math.Min(e.min[i], min[i])
+		e.max[i] = math.Max(e.max[i], max[i])
+	}
+}
+
+func (e *Encoder) write() error {
+	if len(e.buf) > 0 {
+		if _, err := e.w.Write(e.buf); err != nil {
+			e.err = err
+		}
+	}
+	return e.err
+}
+
+// Close finishes the stream. For the FeatureCollectionFormat this closes
+// the "features" array and writes the running bbox, when enabled. The
+// underlying writer is not closed.
+func (e *Encoder) Close() error {
+	if e.err != nil || e.closed {
+		return e.err
+	}
+	e.closed = true
+	if e.format != FeatureCollectionFormat {
+		return nil
+	}
+	e.buf = e.buf[:0]
+	if e.count == 0 {
+		e.buf = append(e.buf, `{"type":"FeatureCollection","features":[`...)
+	}
+	e.buf = append(e.buf, ']')
+	if e.bbox && e.dims > 0 {
+		e.buf = append(e.buf, `,"bbox":[`...)
+		for i := 0; i < e.dims*2; i++ {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			v := e.min[i%e.dims]
+			if i >= e.dims {
+				v = e.max[i%e.dims]
+			}
+			e.buf = strconv.AppendFloat(e.buf, v, 'f', -1, 64)
+		}
+		e.buf = append(e.buf, ']')
+	}
+	e.buf = append(e.buf, '}')
+	return e.write()
+}
@@ -0,0 +1,109 @@
+package geobin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	f1 := ParseJSON(`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":1}}`)
+	g2 := ParseJSON(`{"type":"LineString","coordinates":[[3,4,5],[6,7,8]]}`)
+	fc := ParseJSON(`{"type":"FeatureCollection","features":[` + f1.JSON() + `]}`)
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, FeatureCollectionFormat)
+	assert.NoError(t, e.Close())
+	assert.Equal(t, `{"type":"FeatureCollection","features":[]}`, buf.String())
+
+	buf.Reset()
+	e = NewEncoder(&buf, FeatureCollectionFormat)
+	e.SetRunningBBox(true)
+	assert.NoError(t, e.Encode(f1))
+	assert.NoError(t, e.Encode(g2))
+	assert.NoError(t, e.Encode(fc))
+	assert.Equal(t, errInvalidGeometry, e.Encode(MakeString("hello")))
+	assert.NoError(t, e.Close())
+	assert.NoError(t, e.Close())
+	assert.Equal(t, errEncoderClosed, e.Encode(f1))
+	expect := `{"type":"FeatureCollection","features":[` + f1.JSON() + `,` +
+		`{"type":"Feature","geometry":` + g2.JSON() + `,"properties":null},` +
+		f1.JSON() + `],"bbox":[1,2,5,6,7,8]}`
+	assert.Equal(t, expect, buf.String())
+	assert.NoError(t, Validate(ParseJSON(buf.String()).Binary()))
+
+	// objects without positions
+	buf.Reset()
+	e = NewEncoder(&buf, FeatureCollectionFormat)
+	e.SetRunningBBox(true)
+	empty := ParseJSON(`{"type":"GeometryCollection","geometries":[]}`)
+	assert.NoError(t, e.Encode(empty))
+	assert.NoError(t, e.Close())
+	assert.Equal(t, `{"type":"FeatureCollection","features":[{"type":"Feature","geometry":`+
+		empty.JSON()+`,"properties":null}]}`, buf.String())
+	buf.Reset()
+	e = NewEncoder(&buf, FeatureCollectionFormat)
+	e.SetRunningBBox(true)
+	assert.NoError(t, e.Encode(empty))
+	assert.NoError(t, e.Encode(f1))
+	assert.NoError(t, e.Close())
+	assert.Contains(t, buf.String(), `],"bbox":[1,2,1,2]}`)
+
+	buf.Reset()
+	e = NewEncoder(&buf, TextSequenceFormat)
+	e.SetRunningBBox(true)
+	assert.NoError(t, e.Encode(f1))
+	assert.NoError(t, e.Encode(g2))
+	assert.NoError(t, e.Close())
+	assert.Equal(t, "\x1e"+f1.JSON()+"\n\x1e"+g2.JSON()+"\n", buf.String())
+
+	buf.Reset()
+	e = NewEncoder(&buf, NDJSONFormat)
+	assert.NoError(t, e.Encode(f1))
+	assert.NoError(t, e.Encode(fc))
+	assert.NoError(t, e.Close())
+	assert.Equal(t, f1.JSON()+"\n"+fc.JSON()+"\n", buf.String())
+}
+
+type testFailWriter struct{ n int }
+
+func (w *testFailWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, io.ErrShortWrite
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+	var objs []Object
+	for _, js := range testValidateJSON {
+		if o := ParseJSON(js); o.GeometryType() == Feature {
+			objs = append(objs, o)
+		}
+	}
+	for _, format := range []EncodeFormat{FeatureCollectionFormat, TextSequenceFormat, NDJSONFormat} {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf, format)
+		for _, o := range objs {
+			assert.NoError(t, e.Encode(o))
+		}
+		assert.NoError(t, e.Close())
+		d := NewDecoder(&buf)
+		for _, o := range objs {
+			o2, err := d.Next()
+			if assert.NoError(t, err) {
+				assert.Equal(t, o.JSON(), o2.JSON())
+			}
+		}
+		_, err := d.Next()
+		assert.Equal(t, io.EOF, err)
+	}
+	e := NewEncoder(&testFailWriter{n: 1}, FeatureCollectionFormat)
+	assert.NoError(t, e.Encode(objs[0]))
+	assert.True(t, errors.Is(e.Encode(objs[0]), io.ErrShortWrite))
+	assert.True(t, errors.Is(e.Close(), io.ErrShortWrite))
+}
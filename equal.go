@@ -0,0 +1,244 @@
+package geobin
+
+import (
+	"bytes"
+	"hash/fnv"
+	"math"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/pretty"
+)
+
+// EqualOptions are the options for EqualWithOptions.
+type EqualOptions struct {
+	// Tolerance is the largest difference between two coordinate values
+	// that are equal. The default is zero, which requires exact values.
+	Tolerance float64
+}
+
+// Equal returns true if the objects have the same canonical form. See
+// Canonical.
+func (o Object) Equal(other Object) bool {
+	return o.EqualWithOptions(other, nil)
+}
+
+// EqualWithOptions returns true if the canonical forms of the objects have
+// the same structure, members, and exdata, and coordinates that are within
+// the tolerance.
+func (o Object) EqualWithOptions(other Object, opts *EqualOptions) bool {
+	if opts == nil {
+		opts = &EqualOptions{}
+	}
+	a, b := o.Canonical(), other.Canonical()
+	if !(opts.Tolerance > 0) || !a.IsGeometry() || !a.valid() ||
+		!b.IsGeometry() || !b.valid() {
+		return bytes.Equal(a.data, b.data)
+	}
+	return objectsEqual(a, b, opts.Tolerance)
+}
+
+// Canonical returns a copy of the object in a canonical form, where objects
+// with the same geometry, members, and exdata have the same bytes. That is
+//
+//   - Compacted coordinates are expanded.
+//   - Simple rects are converted into the Polygon, or the MultiPolygon for
+//     3D, that they are exported as.
+//   - Points without members are simple points.
+//   - The bbox is recalculated and not exported.
+//   - The keys of the members are sorted and the whitespace is removed.
+//   - Negative zero coordinates are zero.
+//
+// Strings and invalid objects are returned as is.
+func (o Object) Canonical() Object {
+	if !o.IsGeometry() || !o.valid() {
+		return o
+	}
+	o = transformObject(rectPolygon(o.expandAll()), func(p Position) Position {
+		// adding zero turns -0 into 0
+		return Position{X: p.X + 0, Y: p.Y + 0, Z: p.Z + 0}
+	})
+	return canonicalObject(o)
+}
+
+// Hash64 returns the 64-bit FNV-1a hash of the canonical form of the
+// object. Objects that are Equal have the same hash.
+func (o Object) Hash64() uint64 {
+	h := fnv.New64a()
+	h.Write(o.Canonical().data)
+	return h.Sum64()
+}
+
+// rectPolygon returns a simple rect as the Polygon, or the MultiPolygon for
+// 3D, that it is exported as in GeoJSON. Other objects are returned as is.
+func rectPolygon(o Object) Object {
+	tail := o.data[len(o.data)-1]
+	if tail>>3&1 == 1 || tail>>2&1 == 0 {
+		return o
+	}
+	var rings [][]Position
+	o.ForEachRing(func(r RingReader) bool {
+		var ring []Position
+		r.ForEachPosition(func(p Position) bool {
+			ring = append(ring, p)
+			return true
+		})
+		rings = append(rings, ring)
+		return true
+	})
+	var p Object
+	if tail>>1&1 == 1 {
+		polys := make([][][]Position, len(rings))
+		for i, ring := range rings {
+			polys[i] = [][]Position{ring}
+		}
+		p = makeLevel3(MultiPolygon, polys, 3)
+	} else {
+		p = makeLevel2(Polygon, rings, 2)
+	}
+	if exdata := o.ExData(); len(exdata) > 0 {
+		p = p.SetExData(exdata)
+	}
+	return p
+}
+
+// canonicalObject returns the canonical form of a valid object that has no
+// compacted coordinates, simple rects, or negative zeros.
+func canonicalObject(o Object) Object {
+	tail := o.data[len(o.data)-1]
+	if tail>>3&1 == 0 {
+		return o // simple point
+	}
+	var members []byte
+	if m := o.Members(); len(m) > 0 {
+		members = pretty.Ugly(pretty.PrettyOptions(m, &pretty.Options{SortKeys: true}))
+	}
+	g := o.geometryData()
+	var c Object
+	switch g.Type {
+	case Point:
+		p, _ := readPosition(g.Data, g.Dims)
+		if g.Dims == 3 {
+			c = Make3DPoint(p.X, p.Y, p.Z)
+		} else {
+			c = Make2DPoint(p.X, p.Y)
+		}
+		if len(members) > 0 {
+			c = c.complexPoint()
+		}
+	case Feature:
+		c = featureObject(gjson.Result{}, canonicalObject(o.FeatureGeometry()),
+			gjson.Result{}, gjson.Result{})
+	case GeometryCollection, FeatureCollection:
+		var children []Object
+		o.ForEachChild(func(_ int, child Object) bool {
+			children = append(children, canonicalObject(child))
+			return true
+		})
+		c = collectionObject(g.Type, gjson.Result{}, children)
+	default:
+		min, max := baseMin, baseMax
+		g.ForEachPosition(func(p Position) bool {
+			vals := [3]float64{p.X, p.Y, p.Z}
+			for i := 0; i < g.Dims; i++ {
+				min[i] = math.Min(min[i], vals[i])
+				max[i] = math.Max(max[i], vals[i])
+			}
+			return true
+		})
+		comps := components{tail: 13}
+		if g.Dims == 3 {
+			comps.tail = 15
+		}
+		comps.bbox = make([]byte, g.Dims*16)
+		putBBox(comps.bbox, g.Dims, min, max)
+		comps.data = append([]byte{byte(g.Type) << 4}, g.Data...)
+		c = comps.reconstructObject()
+	}
+	if len(members) > 0 {
+		c = c.setMembers(members)
+	}
+	if exdata := o.ExData(); len(exdata) > 0 {
+		c = c.SetExData(exdata)
+	}
+	return c
+}
+
+// objectsEqual returns true if two canonical objects are equal within the
+// tolerance.
+func objectsEqual(a, b Object, tolerance float64) bool {
+	if a.GeometryType() != b.GeometryType() || a.Dims() != b.Dims() ||
+		!bytes.Equal(a.Members(), b.Members()) || !bytes.Equal(a.ExData(), b.ExData()) {
+		return false
+	}
+	switch a.GeometryType() {
+	case Feature:
+		return objectsEqual(a.FeatureGeometry(), b.FeatureGeometry(), tolerance)
+	case GeometryCollection, FeatureCollection:
+		var children []Object
+		a.ForEachChild(func(_ int, child Object) bool {
+			children = append(children, child)
+			return true
+		})
+		equal := len(children) == b.NumChildren()
+		b.ForEachChild(func(i int, child Object) bool {
+			equal = equal && objectsEqual(children[i], child, tolerance)
+			return equal
+		})
+		return equal
+	}
+	var aparts, bparts []Geometry
+	a.ForEachPart(func(part Geometry) bool {
+		aparts = append(aparts, part)
+		return true
+	})
+	b.ForEachPart(func(part Geometry) bool {
+		bparts = append(bparts, part)
+		return true
+	})
+	if len(aparts) != len(bparts) {
+		return false
+	}
+	for i := range aparts {
+		if !partsEqual(aparts[i], bparts[i], tolerance) {
+			return false
+		}
+	}
+	return true
+}
+
+// partsEqual returns true if two parts have the same rings and positions
+// within the tolerance.
+func partsEqual(a, b Geometry, tolerance float64) bool {
+	var alens, blens []int
+	a.ForEachRing(func(r RingReader) bool {
+		alens = append(alens, r.Len())
+		return true
+	})
+	b.ForEachRing(func(r RingReader) bool {
+		blens = append(blens, r.Len())
+		return true
+	})
+	if a.Type != b.Type || len(alens) != len(blens) {
+		return false
+	}
+	for i := range alens {
+		if alens[i] != blens[i] {
+			return false
+		}
+	}
+	var positions []Position
+	a.ForEachPosition(func(p Position) bool {
+		positions = append(positions, p)
+		return true
+	})
+	var i int
+	equal := true
+	b.ForEachPosition(func(p Position) bool {
+		q := positions[i]
+		equal = math.Abs(p.X-q.X) <= tolerance && math.Abs(p.Y-q.Y) <= tolerance &&
+			math.Abs(p.Z-q.Z) <= tolerance
+		i++
+		return equal
+	})
+	return equal
+}
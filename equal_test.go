@@ -0,0 +1,131 @@
+package geobin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	var tests = []struct {
+		a, b  string
+		equal bool
+	}{
+		// bbox
+		{`{"type":"Point","coordinates":[1,2]}`, `{"type":"Point","coordinates":[1,2],"bbox":[1,2,1,2]}`, true},
+		{`{"type":"Point","coordinates":[1,2,3]}`, `{"type":"Point","coordinates":[1,2,3],"bbox":[0,0,0,5,5,5]}`, true},
+		{`{"type":"LineString","coordinates":[[1,2],[3,4]]}`,
+			`{"type":"LineString","coordinates":[[1,2],[3,4]],"bbox":[0,0,10,10]}`, true},
+		{`{"type":"Point","coordinates":[1,2]}`, `{"type":"Point","coordinates":[1,3]}`, false},
+		{`{"type":"Point","coordinates":[1,2]}`, `{"type":"Point","coordinates":[1,2,0]}`, false},
+		{`{"type":"Point","coordinates":[1,2]}`, `{"type":"MultiPoint","coordinates":[[1,2]]}`, false},
+		{`{"type":"LineString","coordinates":[[1,2],[3,4]]}`, `{"type":"LineString","coordinates":[[3,4],[1,2]]}`, false},
+		{`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]],[[0,0],[1,0],[1,1],[0,0]]]]}`,
+			`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],[[[0,0],[1,0],[1,1],[0,0]]]]}`, false},
+		// members
+		{`{"type":"Feature","id":1,"geometry":{"type":"Point","coordinates":[1,2]},"properties":{"b":1,"a":{"d":2,"c":3}}}`,
+			`{"type":"Feature","properties":{ "a":{"c":3, "d":2},"b":1 },"geometry":{"type":"Point","coordinates":[1,2],"bbox":[1,2,1,2]},"id":1,"bbox":[0,0,5,5]}`,
+			true},
+		{`{"type":"Feature","id":1,"geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":1}}`,
+			`{"type":"Feature","id":2,"geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":1}}`, false},
+		{`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":null}`,
+			`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}`, false},
+		// collections
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[1,2],[3,4]]}]}`,
+			`{"type":"GeometryCollection","bbox":[1,2,3,4],"geometries":[{"type":"Point","coordinates":[1,2],"bbox":[1,2,1,2]},{"type":"LineString","coordinates":[[1,2],[3,4]]}]}`,
+			true},
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]}]}`,
+			`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"Point","coordinates":[1,2]}]}`, false},
+		{`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"x":1, "y":2}}]}`,
+			`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"y":2,"x":1}}]}`, true},
+		// simple rects
+		{`{"type":"Polygon","coordinates":[[[0,0],[0,10],[10,10],[10,0],[0,0]]]}`, Make2DRect(0, 0, 10, 10).JSON(), true},
+		{`{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]]]}`, Make2DRect(0, 0, 10, 10).JSON(), false},
+	}
+	for _, tt := range tests {
+		o1, o2 := ParseJSON(tt.a), ParseJSON(tt.b)
+		assert.True(t, o1.IsGeometry() && o2.IsGeometry(), "%s %s", tt.a, tt.b)
+		assert.Equal(t, tt.equal, o1.Equal(o2), "%s %s", tt.a, tt.b)
+		assert.Equal(t, tt.equal, o2.Equal(o1), "%s %s", tt.a, tt.b)
+		assert.Equal(t, tt.equal, o1.Hash64() == o2.Hash64(), "%s %s", tt.a, tt.b)
+		assert.True(t, o1.Equal(o1))
+	}
+
+	assert.True(t, Make2DRect(0, 0, 10, 10).Equal(
+		ParseJSON(`{"type":"Polygon","coordinates":[[[0,0],[0,10],[10,10],[10,0],[0,0]]]}`)))
+	assert.True(t, Make3DRect(0, 0, 0, 10, 10, 10).Equal(ParseJSON(Make3DRect(0, 0, 0, 10, 10, 10).JSON())))
+	rect := Make2DRect(0, 0, 10, 10).SetExData([]byte("a"))
+	assert.Equal(t, Polygon, rect.Canonical().GeometryType())
+	assert.Equal(t, "a", string(rect.Canonical().ExData()))
+	assert.True(t, Make2DPoint(math.Copysign(0, -1), 1).Equal(Make2DPoint(0, 1)))
+	assert.True(t, MakeString("hello").Equal(MakeString("hello")))
+	assert.False(t, MakeString("hello").Equal(MakeString("world")))
+	assert.False(t, MakeString("hello").Equal(Make2DPoint(1, 2)))
+
+	// exdata
+	p := Make2DPoint(1, 2)
+	assert.True(t, p.SetExData([]byte("a")).Equal(p.SetExData([]byte("a"))))
+	assert.False(t, p.SetExData([]byte("a")).Equal(p.SetExData([]byte("b"))))
+	assert.False(t, p.SetExData([]byte("a")).Equal(p))
+
+	// compacted
+	line := ParseJSON(`{"type":"LineString","coordinates":[[1.5,2.25],[3.125,-4]]}`)
+	assert.True(t, line.Compact(1e-7).IsCompact())
+	assert.True(t, line.Equal(line.Compact(1e-7)))
+	assert.Equal(t, line.Hash64(), line.Compact(1e-7).Hash64())
+}
+
+func TestEqualWithOptions(t *testing.T) {
+	a := ParseJSON(`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,0]]]},"properties":{"a":1}}`)
+	b := ParseJSON(`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[10.0000001,0],[10,10],[0,0]]]},"properties":{"a":1}}`)
+	assert.False(t, a.Equal(b))
+	assert.False(t, a.EqualWithOptions(b, &EqualOptions{Tolerance: 1e-8}))
+	assert.True(t, a.EqualWithOptions(b, &EqualOptions{Tolerance: 1e-6}))
+	assert.True(t, b.EqualWithOptions(a, &EqualOptions{Tolerance: 1e-6}))
+	assert.True(t, a.EqualWithOptions(a, nil))
+
+	// the structure and members must match
+	c := ParseJSON(`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,0]]]},"properties":{"a":2}}`)
+	assert.False(t, a.EqualWithOptions(c, &EqualOptions{Tolerance: 1}))
+	d := ParseJSON(`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]]]},"properties":{"a":1}}`)
+	assert.False(t, a.EqualWithOptions(d, &EqualOptions{Tolerance: 100}))
+	e := ParseJSON(`{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[[[[0,0],[10,0],[10,10],[0,0]]]]},"properties":{"a":1}}`)
+	assert.False(t, a.EqualWithOptions(e, &EqualOptions{Tolerance: 100}))
+
+	coll1 := ParseJSON(`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}]}`)
+	coll2 := ParseJSON(`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2.1]},{"type":"MultiPoint","coordinates":[[1,2],[3.1,4]]}]}`)
+	assert.True(t, coll1.EqualWithOptions(coll2, &EqualOptions{Tolerance: 0.2}))
+	assert.False(t, coll1.EqualWithOptions(coll2, &EqualOptions{Tolerance: 0.05}))
+	assert.False(t, MakeString("hello").EqualWithOptions(MakeString("hellO"), &EqualOptions{Tolerance: 1}))
+}
+
+func TestCanonical(t *testing.T) {
+	for _, json := range []string{
+		`{"type":"Point","coordinates":[1,2],"bbox":[0,0,5,5]}`,
+		`{"type":"MultiPoint","coordinates":[[1,2],[3,4]],"bbox":[0,0,5,5]}`,
+		`{"type":"LineString","coordinates":[[1,2,3],[3,4,5]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,0]],[[1,1],[2,1],[2,2],[1,1]]]}`,
+		`{"type":"LineString","coordinates":[]}`,
+		`{"type":"Feature","id":"a","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"z":[1, 2],"a":null}}`,
+		`{"type":"FeatureCollection","bbox":[0,0,5,5],"features":[{"type":"Feature","geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]},"properties":{}}]}`,
+	} {
+		o := ParseJSON(json)
+		c := o.Canonical()
+		assert.Nil(t, Validate(c.Binary()), json)
+		assert.True(t, o.Equal(c), json)
+		assert.Equal(t, c.Binary(), c.Canonical().Binary(), json)
+		assert.Equal(t, c.Binary(), ParseJSON(c.JSON()).Canonical().Binary(), json)
+		assert.NotContains(t, c.JSON(), `"bbox"`, json)
+	}
+	assert.Equal(t, Make2DPoint(1, 2).Binary(),
+		ParseJSON(`{"type":"Point","coordinates":[1,2],"bbox":[1,2,1,2]}`).Canonical().Binary())
+	assert.Equal(t, `{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"id":"a","properties":{"a":null,"z":[1,2]}}`,
+		ParseJSON(`{"type":"Feature","properties":{"z":[1, 2],"a":null},"id":"a","geometry":{"type":"Point","coordinates":[1,2]}}`).Canonical().JSON())
+	// foreign members
+	o, err := ParseJSONWithOptions(`{"type":"Point","coordinates":[1,2],"b":1,"a":2}`, &ParseJSONOptions{ForeignMembers: true})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":2,"b":1}`, string(o.Canonical().Members()))
+	assert.False(t, o.Equal(Make2DPoint(1, 2)))
+	assert.Equal(t, "hello", MakeString("hello").Canonical().String())
+}
@@ -0,0 +1,78 @@
+package geobin
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// ParseError is the error returned when GeoJSON cannot be parsed. It wraps
+// the reason, so errors.Is and errors.As can be used to check it.
+type ParseError struct {
+	// Path is the JSON path of the failing member, such as
+	// "features.3.geometry.coordinates". Empty for the root object.
+	Path string
+	// Offset is the byte offset of the failing member in the input, or of
+	// its nearest parent when the member is missing.
+	Offset int
+	// Reason is the cause of the failure.
+	Reason error
+}
+
+func (e *ParseError) Error() string {
+	if e.Path == "" {
+		return e.Reason.Error()
+	}
+	return e.Path + ": " + e.Reason.Error()
+}
+
+// Unwrap returns the reason.
+func (e *ParseError) Unwrap() error {
+	return e.Reason
+}
+
+// parseError returns a ParseError for the path.
+func parseError(path string, reason error) error {
+	return &ParseError{Path: path, Reason: reason}
+}
+
+// prefixError returns the error with the key prepended to its path. Used by
+// the parent of a failing child.
+func prefixError(key string, err error) error {
+	if e, ok := err.(*ParseError); ok {
+		return &ParseError{Path: joinPath(key, e.Path), Reason: e.Reason}
+	}
+	return &ParseError{Path: key, Reason: err}
+}
+
+// locateError sets the offset of a ParseError to the position of its path
+// in the json.
+func locateError(json string, err error) error {
+	e, ok := err.(*ParseError)
+	if !ok {
+		return err
+	}
+	for path := e.Path; path != ""; {
+		if res := gjson.Get(json, path); res.Exists() {
+			e.Offset = res.Index
+			break
+		}
+		i := strings.LastIndexByte(path, '.')
+		if i == -1 {
+			break
+		}
+		path = path[:i]
+	}
+	return e
+}
+
+// joinPath appends a key, or an index, to a path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	if key == "" {
+		return path
+	}
+	return path + "." + key
+}
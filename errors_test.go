@@ -0,0 +1,55 @@
+package geobin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseError(t *testing.T) {
+	var tests = []struct {
+		js     string
+		err    error
+		path   string
+		offset string // the input at the offset
+	}{
+		{`{"type":"Pointy"}`, errInvalidType, "type", `"Pointy"`},
+		{`{"coordinates":[1,2]}`, errInvalidType, "type", `{"coordinates"`},
+		{`{"type":"Point"}`, errInvalidCoordinates, "coordinates", `{"type"`},
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString"}]}`,
+			errInvalidCoordinates, "geometries.1.coordinates", `{"type":"LineString"}`},
+		{`{"type":"Feature","geometry":{"type":"Polygon"}}`,
+			errInvalidCoordinates, "geometry.coordinates", `{"type":"Polygon"}`},
+		{`{"type":"FeatureCollection","features":[` +
+			`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}},` +
+			`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}},` +
+			`{"type":"Feature","geometry":{"type":"GeometryCollection","geometries":[{"type":"Feature"}]}}]}`,
+			errInvalidType, "features.2.geometry.geometries.0.geometry.type", `{"type":"Feature"}]`},
+	}
+	for _, test := range tests {
+		_, err := ParseJSONWithErrors(test.js)
+		if !assert.Error(t, err, test.js) {
+			continue
+		}
+		assert.True(t, errors.Is(err, test.err), test.js)
+		var perr *ParseError
+		if !assert.True(t, errors.As(err, &perr), test.js) {
+			continue
+		}
+		assert.Equal(t, test.path, perr.Path, test.js)
+		assert.Equal(t, test.path+": "+test.err.Error(), perr.Error(), test.js)
+		assert.True(t, strings.HasPrefix(test.js[perr.Offset:], test.offset),
+			"%s: %s", test.js, test.js[perr.Offset:])
+	}
+	_, err := ParseJSONWithOptions(`{"type":"LineString","coordinates":[[1,2],[3,4,5]]}`+
+		"\n\n", &ParseJSONOptions{Strict: true})
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, "coordinates.1", perr.Path)
+		assert.Equal(t, 42, perr.Offset)
+	}
+	_, err = ParseJSONWithOptions(`{`, &ParseJSONOptions{Strict: true})
+	assert.Equal(t, "invalid json", err.Error())
+}
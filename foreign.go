@@ -0,0 +1,70 @@
+package geobin
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/pretty"
+)
+
+// isStructuralMember returns true if the key is a member that is defined by
+// the GeoJSON spec for the type, and is therefore not a foreign member.
+func isStructuralMember(typ GeometryType, key string) bool {
+	switch key {
+	case "type", "bbox":
+		return true
+	case "coordinates":
+		return geomDepth(typ) != -1
+	case "geometries":
+		return typ == GeometryCollection
+	case "geometry":
+		return typ == Feature
+	case "features":
+		return typ == FeatureCollection
+	}
+	return false
+}
+
+// withForeignMembers returns a copy of a valid object with the members
+// replaced by the foreign members of the GeoJSON, in the order that they
+// appear. For a Feature this includes the "id" and "properties".
+func withForeignMembers(o Object, json gjson.Result) Object {
+	typ := o.GeometryType()
+	var members []byte
+	json.ForEach(func(key, val gjson.Result) bool {
+		if isStructuralMember(typ, key.String()) {
+			return true
+		}
+		if len(members) == 0 {
+			members = append(members, '{')
+		} else {
+			members = append(members, ',')
+		}
+		members = append(members, key.Raw...)
+		members = append(members, ':')
+		members = append(members, val.Raw...)
+		return true
+	})
+	if len(members) == 0 {
+		return o
+	}
+	members = pretty.UglyInPlace(append(members, '}'))
+	return o.complexPoint().setMembers(members)
+}
+
+// complexPoint returns a simple point as a complex point, which can hold
+// members. Other objects are returned as is.
+func (o Object) complexPoint() Object {
+	tail := o.data[len(o.data)-1]
+	if tail&1 == 0 || tail>>2&1 == 1 || tail>>3&1 == 1 {
+		return o
+	}
+	dims := 2
+	if tail>>1&1 == 1 {
+		dims = 3
+	}
+	// [RAW] = [BBOX][HEAD][POSITION][TAIL]
+	raw := make([]byte, 0, dims*16+2)
+	raw = append(raw, o.data[:dims*8]...)
+	raw = append(raw, byte(Point)<<4)
+	raw = append(raw, o.data[:dims*8]...)
+	return Object{append(raw, tail|8)}
+}
@@ -0,0 +1,54 @@
+package geobin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForeignMembers(t *testing.T) {
+	opts := &ParseJSONOptions{ForeignMembers: true}
+	var tests = []struct{ in, out string }{
+		{`{"type":"Point","coordinates":[1,2],"title":"Home"}`,
+			`{"type":"Point","coordinates":[1,2],"title":"Home"}`},
+		{`{"title":"Home","type":"Point","coordinates":[1,2,3]}`,
+			`{"type":"Point","coordinates":[1,2,3],"title":"Home"}`},
+		{`{"type":"Point","bbox":[1,2,1,2],"coordinates":[1,2],"a":1}`,
+			`{"type":"Point","bbox":[1,2,1,2],"coordinates":[1,2],"a":1}`},
+		{`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]],"crs":{"type":"name"}}`,
+			`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]],"crs":{"type":"name"}}`},
+		{`{"type":"Feature","a":1,"geometry":{"type":"Point","coordinates":[1,2],"b":2},"id":3,"properties":{"c":4}}`,
+			`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2],"b":2},"a":1,"id":3,"properties":{"c":4}}`},
+		{`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":null}],"name":"places"}`,
+			`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":null}],"name":"places"}`},
+		{`{"type":"GeometryCollection","geometries":[],"coordinates":[1,2]}`,
+			`{"type":"GeometryCollection","geometries":[],"coordinates":[1,2]}`},
+		{`{"type":"LineString","coordinates":[[1,2],[3,4]]}`,
+			`{"type":"LineString","coordinates":[[1,2],[3,4]]}`},
+	}
+	for _, test := range tests {
+		o, err := ParseJSONWithOptions(test.in, opts)
+		if assert.NoError(t, err, test.in) {
+			assert.Equal(t, test.out, o.JSON(), test.in)
+			assert.NoError(t, Validate(o.Binary()), test.in)
+		}
+	}
+	o, _ := ParseJSONWithOptions(`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"id":3,"properties":{"c":4},"a":1}`, opts)
+	assert.Equal(t, int64(3), o.ID().Int())
+	assert.Equal(t, int64(4), o.Property("c").Int())
+	o, err := o.SetProperty("c", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"id":3,"properties":{"c":5},"a":1}`, o.JSON())
+}
+
+func TestForeignMembersDefault(t *testing.T) {
+	for _, js := range testValidateJSON {
+		o1 := ParseJSON(js)
+		o2, err := ParseJSONWithOptions(js, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, o1.Binary(), o2.Binary(), js)
+	}
+	o, err := ParseJSONWithOptions(`{"type":"Point","coordinates":[1,2],"title":"Home"}`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"Point","coordinates":[1,2]}`, o.JSON())
+}
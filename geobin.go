@@ -33,8 +33,40 @@ func ParseJSON(json string) Object {
 	return o
 }
 
+// ParseJSONWithErrors parses GeoJSON and returns an geobin object, or a
+// *ParseError if the GeoJSON is invalid.
 func ParseJSONWithErrors(json string) (Object, error) {
-	return objectFromJSON(json)
+	return ParseJSONWithOptions(json, nil)
+}
+
+// ParseJSONOptions are the options for ParseJSONWithOptions.
+type ParseJSONOptions struct {
+	// ForeignMembers keeps every unknown top-level member of every GeoJSON
+	// object, such as "title" or "crs", and not just the "id" and
+	// "properties" of a Feature. The members are written back by JSON.
+	ForeignMembers bool
+	// Strict rejects GeoJSON that does not follow RFC 7946, such as unclosed
+	// rings, out of range positions, and members of the wrong type. The
+	// errors include the JSON path of the problem.
+	Strict bool
+}
+
+// ParseJSONWithOptions parses GeoJSON using the provided options. A nil opts
+// uses the defaults, which is the same as ParseJSONWithErrors.
+func ParseJSONWithOptions(json string, opts *ParseJSONOptions) (Object, error) {
+	if opts == nil {
+		opts = &ParseJSONOptions{}
+	}
+	if opts.Strict {
+		if err := strictJSON(json); err != nil {
+			return Object{}, locateError(json, err)
+		}
+	}
+	o, err := objectFromJSON(json, opts)
+	if err != nil {
+		return Object{}, locateError(json, err)
+	}
+	return o, nil
 }
 
 // WrapBinary creates an object by wrapping data.
@@ -88,6 +120,9 @@ func (o Object) AppendString(b []byte) []byte {
 // StringBytes returns a string representation of the object as bytes.
 func (o Object) StringBytes() []byte {
 	if o.IsGeometry() {
+		if !o.valid() {
+			return []byte(`{"type":"Unknown"}`)
+		}
 		return appendGeojsonBytes(nil, o)
 	}
 	if len(o.data) == 0 {
@@ -109,6 +144,9 @@ func (o Object) JSON() string {
 // to the provided input bytes and returns the modified slice.
 func (o Object) AppendJSON(b []byte) []byte {
 	if o.IsGeometry() {
+		if !o.valid() {
+			return append(b, `{"type":"Unknown"}`...)
+		}
 		return appendGeojsonBytes(b, o)
 	}
 	if len(o.data) == 0 {
@@ -254,7 +292,8 @@ func (o Object) ExData() []byte {
 }
 
 // Members returns the Members component of the object. This is a JSON
-// document containing the "id" and "properties" members.
+// document containing the "id" and "properties" members, or any foreign
+// members when parsed with the ForeignMembers option.
 // returns nil if no members are defined.
 func (o Object) Members() []byte {
 	if len(o.data) == 0 {
@@ -281,10 +320,19 @@ func (o Object) Members() []byte {
 			bboxSize = 16
 		}
 	}
+	if len(o.data) <= bboxSize {
+		return nil // invalid
+	}
 	// complex, let's pull the geom data
 	geomData := o.data[bboxSize:]
 	if geomData[0]&1 == 1 {
+		if len(geomData) < 5 {
+			return nil // invalid
+		}
 		sz := int(binary.LittleEndian.Uint32(geomData[1:]))
+		if sz > len(geomData)-5 {
+			return nil // invalid
+		}
 		return geomData[5 : 5+sz : 5+sz]
 	}
 	return nil
@@ -338,12 +386,21 @@ func (o Object) parseComponents() (c components) {
 				bboxSize = 16
 			}
 		}
+		if len(o.data)-1 < bboxSize {
+			return components{} // invalid
+		}
 		c.bbox = o.data[:bboxSize]
 	}
 
 	if c.tail>>4&1 == 1 {
 		// haseexdata
+		if len(o.data)-5 < bboxSize {
+			return components{} // invalid
+		}
 		exdataSize := int(binary.LittleEndian.Uint32(o.data[len(o.data)-5:]))
+		if exdataSize > len(o.data)-5-bboxSize {
+			return components{} // invalid
+		}
 		c.exdata = o.data[len(o.data)-5-exdataSize : len(o.data)-5]
 		c.data = o.data[bboxSize : len(o.data)-5-exdataSize]
 	} else {
@@ -437,6 +494,9 @@ func appendGeojsonCoordinates(json, data []byte, depth, dims int) ([]byte, []byt
 }
 
 func appendGeojsonComplexBytes(json []byte, o Object) []byte {
+	if o.isCompact() {
+		o = o.expand()
+	}
 	json = append(json, '{')
 	c := o.parseComponents()
 	var dims int
@@ -738,9 +798,14 @@ func tailFromBBoxJSON(bbox gjson.Result) (tail byte, data []byte) {
 
 func level1FromJSON(typ GeometryType, bbox, coords gjson.Result) (Object, error) {
 	if !coords.Exists() {
-		return Object{}, errInvalidCoordinates
+		return Object{}, parseError("coordinates", errInvalidCoordinates)
 	}
 	vals, dims, min, max := valsFromCoords1(coords, baseMin, baseMax)
+	return level1Object(typ, bbox, vals, dims, min, max), nil
+}
+
+// level1Object creates a MultiPoint or LineString from values.
+func level1Object(typ GeometryType, bbox gjson.Result, vals [][3]float64, dims int, min, max [3]float64) Object {
 	if dims < 2 {
 		dims = 2
 	}
@@ -753,14 +818,19 @@ func level1FromJSON(typ GeometryType, bbox, coords gjson.Result) (Object, error)
 	}
 	raw = appendGeomData1(raw, vals, dims)
 	raw = append(raw, tail)
-	return Object{raw}, nil
+	return Object{raw}
 }
 
 func level2FromJSON(typ GeometryType, bbox, coords gjson.Result) (Object, error) {
 	if !coords.Exists() {
-		return Object{}, errInvalidCoordinates
+		return Object{}, parseError("coordinates", errInvalidCoordinates)
 	}
 	vals, dims, min, max := valsFromCoords2(coords, baseMin, baseMax)
+	return level2Object(typ, bbox, vals, dims, min, max), nil
+}
+
+// level2Object creates a MultiLineString or Polygon from values.
+func level2Object(typ GeometryType, bbox gjson.Result, vals [][][3]float64, dims int, min, max [3]float64) Object {
 	if dims < 2 {
 		dims = 2
 	}
@@ -780,7 +850,7 @@ func level2FromJSON(typ GeometryType, bbox, coords gjson.Result) (Object, error)
 	}
 	raw = appendGeomData2(raw, vals, dims)
 	raw = append(raw, tail)
-	return Object{raw}, nil
+	return Object{raw}
 }
 
 func polyRectIsNormal(points [][3]float64, x, y, z int) bool {
@@ -815,9 +885,14 @@ func polyRectIsNormal(points [][3]float64, x, y, z int) bool {
 }
 func level3FromJSON(typ GeometryType, bbox, coords gjson.Result) (Object, error) {
 	if !coords.Exists() {
-		return Object{}, errInvalidCoordinates
+		return Object{}, parseError("coordinates", errInvalidCoordinates)
 	}
 	vals, dims, min, max := valsFromCoords3(coords, baseMin, baseMax)
+	return level3Object(typ, bbox, vals, dims, min, max), nil
+}
+
+// level3Object creates a MultiPolygon from values.
+func level3Object(typ GeometryType, bbox gjson.Result, vals [][][][3]float64, dims int, min, max [3]float64) Object {
 	if dims < 2 {
 		dims = 2
 	}
@@ -860,12 +935,12 @@ func level3FromJSON(typ GeometryType, bbox, coords gjson.Result) (Object, error)
 	}
 	raw = appendGeomData3(raw, vals, dims)
 	raw = append(raw, tail)
-	return Object{raw}, nil
+	return Object{raw}
 }
 func pointFromJSON(bbox, coords gjson.Result) (Object, error) {
 	typ := Point
 	if !coords.Exists() {
-		return Object{}, errInvalidCoordinates
+		return Object{}, parseError("coordinates", errInvalidCoordinates)
 	}
 	vals, dims := valsFromCoords0(coords)
 	if dims < 2 {
@@ -903,25 +978,38 @@ func pointFromJSON(bbox, coords gjson.Result) (Object, error) {
 	return Object{data}, nil
 }
 
-func collectionFromJSON(typ GeometryType, bbox, geoms gjson.Result) (Object, error) {
-	var dims int
-	min, max := baseMin, baseMax
+func collectionFromJSON(typ GeometryType, bbox, geoms gjson.Result, opts *ParseJSONOptions) (Object, error) {
 	var vals []Object
 	var invalid bool
 	var lasterr error
+	key := "geometries"
+	if typ == FeatureCollection {
+		key = "features"
+	}
 	geoms.ForEach(func(_, val gjson.Result) bool {
-		g, err := objectFromJSON(val.Raw)
-		if err != nil {
-			lasterr = err
-			invalid = true
-			return false
+		g, err := objectFromJSON(val.Raw, opts)
+		if err == nil && !g.IsGeometry() {
+			err = errInvalidGeometry
 		}
-		if !g.IsGeometry() {
-			lasterr = errInvalidGeometry
+		if err != nil {
+			lasterr = prefixError(key+"."+strconv.Itoa(len(vals)), err)
 			invalid = true
 			return false
 		}
 		vals = append(vals, g)
+		return true
+	})
+	if invalid {
+		return Object{}, lasterr
+	}
+	return collectionObject(typ, bbox, vals), nil
+}
+
+// collectionObject creates a collection from geometry objects.
+func collectionObject(typ GeometryType, bbox gjson.Result, vals []Object) Object {
+	var dims int
+	min, max := baseMin, baseMax
+	for _, g := range vals {
 		gdims := g.Dims()
 		if gdims > dims {
 			dims = gdims
@@ -935,10 +1023,6 @@ func collectionFromJSON(typ GeometryType, bbox, geoms gjson.Result) (Object, err
 				max[i] = gmax[i]
 			}
 		}
-		return true
-	})
-	if invalid {
-		return Object{}, lasterr
 	}
 	if dims < 2 {
 		dims = 2
@@ -960,17 +1044,22 @@ func collectionFromJSON(typ GeometryType, bbox, geoms gjson.Result) (Object, err
 		raw = append(raw, data...)
 	}
 	raw = append(raw, tail)
-	return Object{raw}, nil
+	return Object{raw}
 }
-func featureFromJSON(bbox, geom, id, props gjson.Result) (Object, error) {
-	typ := byte(Feature)
-	g, err := objectFromJSON(geom.Raw)
-	if err != nil {
-		return Object{}, err
+func featureFromJSON(bbox, geom, id, props gjson.Result, opts *ParseJSONOptions) (Object, error) {
+	g, err := objectFromJSON(geom.Raw, opts)
+	if err == nil && !g.IsGeometry() {
+		err = errInvalidGeometry
 	}
-	if !g.IsGeometry() {
-		return Object{}, errInvalidGeometry
+	if err != nil {
+		return Object{}, prefixError("geometry", err)
 	}
+	return featureObject(bbox, g, id, props), nil
+}
+
+// featureObject creates a Feature from a geometry object.
+func featureObject(bbox gjson.Result, g Object, id, props gjson.Result) Object {
+	typ := byte(Feature)
 	var exportBBox bool
 	tail, bboxData := tailFromBBoxJSON(bbox)
 	if tail == 0 {
@@ -1038,27 +1127,30 @@ func featureFromJSON(bbox, geom, id, props gjson.Result) (Object, error) {
 	binary.LittleEndian.PutUint32(raw[len(raw)-4:], uint32(len(g.data)))
 	raw = append(raw, g.data...)
 	raw = append(raw, tail)
-	return Object{raw}, nil
+	return Object{raw}
 }
 
-func objectFromJSON(json string) (Object, error) {
-	switch gjson.Get(json, "type").String() {
+func objectFromJSON(json string, opts *ParseJSONOptions) (Object, error) {
+	var o Object
+	var err error
+	typ := gjson.Get(json, "type")
+	switch typ.String() {
 	default:
-		return Object{}, errInvalidType
+		return Object{}, parseError("type", errInvalidType)
 	case "Point":
-		return pointFromJSON(gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
+		o, err = pointFromJSON(gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
 	case "MultiPoint":
-		return level1FromJSON(MultiPoint, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
+		o, err = level1FromJSON(MultiPoint, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
 	case "LineString":
-		return level1FromJSON(LineString, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
+		o, err = level1FromJSON(LineString, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
 	case "MultiLineString":
-		return level2FromJSON(MultiLineString, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
+		o, err = level2FromJSON(MultiLineString, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
 	case "Polygon":
-		return level2FromJSON(Polygon, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
+		o, err = level2FromJSON(Polygon, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
 	case "MultiPolygon":
-		return level3FromJSON(MultiPolygon, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
+		o, err = level3FromJSON(MultiPolygon, gjson.Get(json, "bbox"), gjson.Get(json, "coordinates"))
 	case "GeometryCollection":
-		return collectionFromJSON(GeometryCollection, gjson.Get(json, "bbox"), gjson.Get(json, "geometries"))
+		o, err = collectionFromJSON(GeometryCollection, gjson.Get(json, "bbox"), gjson.Get(json, "geometries"), opts)
 	case "Feature":
 		var id, props, geom, bbox gjson.Result
 		gjson.Parse(json).ForEach(func(key, val gjson.Result) bool {
@@ -1074,10 +1166,14 @@ func objectFromJSON(json string) (Object, error) {
 			}
 			return true
 		})
-		return featureFromJSON(bbox, geom, id, props)
+		o, err = featureFromJSON(bbox, geom, id, props, opts)
 	case "FeatureCollection":
-		return collectionFromJSON(FeatureCollection, gjson.Get(json, "bbox"), gjson.Get(json, "features"))
+		o, err = collectionFromJSON(FeatureCollection, gjson.Get(json, "bbox"), gjson.Get(json, "features"), opts)
 	}
+	if err != nil || !opts.ForeignMembers {
+		return o, err
+	}
+	return withForeignMembers(o, gjson.Parse(json)), nil
 }
 
 // GeometryType represents a geojson geometry type
@@ -1161,6 +1257,9 @@ func (o Object) GeometryType() GeometryType {
 			return Point
 		}
 	}
+	if len(o.data) <= bboxSize {
+		return Unknown // invalid
+	}
 	// complex
 	return GeometryType(o.data[bboxSize] >> 4)
 }
@@ -1268,12 +1367,27 @@ func (o Object) Geometry() Geometry {
 		}
 		return geom
 	}
+	if len(o.data) <= bboxSize {
+		return Geometry{} // invalid
+	}
+	if o.isCompact() {
+		if !o.valid() {
+			return Geometry{} // invalid
+		}
+		return o.expand().Geometry()
+	}
 	// complex, let's pull the geom data
 	geom.Data = o.data[bboxSize:]
 	geom.Type = GeometryType(geom.Data[0] >> 4)
 	if geom.Data[0]&1 == 1 {
 		// has members, skip over
+		if len(geom.Data) < 5 {
+			return Geometry{} // invalid
+		}
 		sz := int(binary.LittleEndian.Uint32(geom.Data[1:]))
+		if sz > len(geom.Data)-5 {
+			return Geometry{} // invalid
+		}
 		geom.Data = geom.Data[5+sz:]
 	} else {
 		geom.Data = geom.Data[1:]
@@ -1287,43 +1401,62 @@ func (g Geometry) PositionCount() int {
 	if g.Simple {
 		return 2
 	}
+	// malformed data is counted up to the point where it becomes unreadable
 	var count int
 	switch g.Type {
 	case MultiPoint, LineString:
-		count, _ = readUint32(g.Data)
+		count, _ = countPositions(g.Data, 1, g.Dims)
 	case MultiLineString, Polygon:
-		n, data := readUint32(g.Data)
-		for i := 0; i < n; i++ {
-			var nn int
-			nn, data = readUint32(data)
-			count += nn
-			data = data[nn*8*g.Dims:]
-		}
+		count, _ = countPositions(g.Data, 2, g.Dims)
 	case MultiPolygon:
-		n, data := readUint32(g.Data)
-		for i := 0; i < n; i++ {
-			var nn int
-			nn, data = readUint32(data)
-			for i := 0; i < nn; i++ {
-				var nnn int
-				nnn, data = readUint32(data)
-				count += nnn
-				data = data[nnn*8*g.Dims:]
-			}
-		}
+		count, _ = countPositions(g.Data, 3, g.Dims)
 	case GeometryCollection, FeatureCollection:
+		if len(g.Data) < 4 {
+			return 0
+		}
 		n, data := readUint32(g.Data)
-		for i := 0; i < n; i++ {
+		for i := 0; i < n && len(data) >= 4; i++ {
 			var sz int
 			sz, data = readUint32(data)
+			if sz > len(data) {
+				break
+			}
 			o := Object{data[:sz]}
 			count += o.Geometry().PositionCount()
 			data = data[sz:]
 		}
 	case Feature:
+		if len(g.Data) < 4 {
+			return 0
+		}
 		sz, data := readUint32(g.Data)
+		if sz > len(data) {
+			return 0
+		}
 		o := Object{data[:sz]}
 		count = o.Geometry().PositionCount()
 	}
 	return count
 }
+
+// countPositions counts the positions in a [UINT32][...] coordinates block
+// and returns the remaining data. The depth is the number of nested counts.
+func countPositions(data []byte, depth, dims int) (int, []byte) {
+	if len(data) < 4 || dims < 2 {
+		return 0, nil
+	}
+	n, data := readUint32(data)
+	if depth == 1 {
+		if n > len(data)/(dims*8) {
+			return 0, nil
+		}
+		return n, data[n*dims*8:]
+	}
+	var count int
+	for i := 0; i < n && data != nil; i++ {
+		var nn int
+		nn, data = countPositions(data, depth-1, dims)
+		count += nn
+	}
+	return count, data
+}
@@ -0,0 +1,140 @@
+package geobin
+
+import (
+	"sort"
+
+	"github.com/tidwall/tile38/geojson/geohash"
+)
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashCover returns the geohash cells that cover the object. The cells
+// that intersect the object are found at the minPrecision, and are then
+// split into the smaller cells of the next precision, up to maxPrecision,
+// as long as there are no more than maxCells. Cells that are fully inside
+// of a polygon are not split. The minPrecision takes priority over
+// maxCells, and a maxCells of zero is unlimited. The cells are sorted.
+// Returns nil for an invalid precision, which must be from 1 to 12, or for
+// an object without positions.
+func (o Object) GeohashCover(minPrecision, maxPrecision, maxCells int) []string {
+	return o.geohashCover(minPrecision, maxPrecision, maxCells, false)
+}
+
+// GeohashInterior is like GeohashCover, but only returns the cells that are
+// fully inside of the polygons of the object. Cells that are partially
+// inside are split until the maxPrecision, or until there would be more
+// than maxCells, and are then dropped.
+func (o Object) GeohashInterior(minPrecision, maxPrecision, maxCells int) []string {
+	return o.geohashCover(minPrecision, maxPrecision, maxCells, true)
+}
+
+// geohashCell is a cell that intersects the object.
+type geohashCell struct {
+	hash   string
+	inside bool // fully inside of a polygon
+}
+
+func (o Object) geohashCover(minPrecision, maxPrecision, maxCells int, interior bool) []string {
+	if minPrecision < 1 || maxPrecision < minPrecision || maxPrecision > 12 ||
+		!o.IsGeometry() || !o.valid() {
+		return nil
+	}
+	var cells []string
+	// the cells of the next precision, which starts with the whole world
+	frontier := []geohashCell{{}}
+	for precision := 0; len(frontier) > 0; precision++ {
+		var next []geohashCell
+		for i, c := range frontier {
+			if precision > 0 && (c.inside && precision >= minPrecision ||
+				precision == maxPrecision) {
+				if c.inside || !interior {
+					cells = append(cells, c.hash)
+				}
+				continue
+			}
+			children := make([]geohashCell, 0, len(geohashBase32))
+			for j := 0; j < len(geohashBase32); j++ {
+				child := geohashCell{hash: c.hash + geohashBase32[j:j+1], inside: c.inside}
+				intersects := c.inside
+				if !c.inside {
+					intersects, child.inside = cellRelation(o, child.hash)
+				}
+				if intersects {
+					children = append(children, child)
+				}
+			}
+			// the cells after the split, including the unprocessed cells of
+			// the frontier
+			count := len(cells) + len(frontier) - i - 1 + len(next) + len(children)
+			if precision >= minPrecision && maxCells > 0 && count > maxCells {
+				if !interior {
+					cells = append(cells, c.hash)
+				}
+				continue
+			}
+			next = append(next, children...)
+		}
+		frontier = next
+	}
+	sort.Strings(cells)
+	return cells
+}
+
+// cellRelation returns true if the object intersects the geohash cell, and
+// if the cell is fully inside of a polygon.
+func cellRelation(o Object, cell string) (intersects, inside bool) {
+	swLat, swLon, neLat, neLon, _ := geohash.Bounds(cell)
+	rect := BBox{Min: Position{X: swLon, Y: swLat}, Max: Position{X: neLon, Y: neLat}}
+	o.ForEachPart(func(part Geometry) bool {
+		switch part.Type {
+		case Point:
+			part.ForEachPosition(func(p Position) bool {
+				intersects = intersects || pointInsideRect(p, rect)
+				return false
+			})
+		case LineString:
+			part.ForEachRing(func(r RingReader) bool {
+				if r.Len() == 1 && pointInsideRect(r.At(0), rect) {
+					intersects = true
+				}
+				for i := 1; i < r.Len() && !intersects; i++ {
+					_, _, intersects = clipSegment(r.At(i-1), r.At(i), rect)
+				}
+				return false
+			})
+		default:
+			// polygons, including simple rects
+			var rings []RingReader
+			forEachPolygonRing(part, func(_ int, r RingReader) bool {
+				rings = append(rings, r)
+				return true
+			})
+			var crosses bool
+			for _, r := range rings {
+				for i := 1; i < r.Len() && !crosses; i++ {
+					a, b, ok := clipSegment(r.At(i-1), r.At(i), rect)
+					if ok {
+						// a segment crosses the cell when the middle of the
+						// part inside of the cell is not on its edge
+						intersects = true
+						mid := Position{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+						crosses = mid.X > rect.Min.X && mid.X < rect.Max.X &&
+							mid.Y > rect.Min.Y && mid.Y < rect.Max.Y
+					}
+				}
+			}
+			center := Position{X: (swLon + neLon) / 2, Y: (swLat + neLat) / 2}
+			if !crosses && len(rings) > 0 && ringContains(rings[0], center) {
+				inside = true
+				for _, hole := range rings[1:] {
+					if ringContains(hole, center) {
+						inside = false
+					}
+				}
+				intersects = intersects || inside
+			}
+		}
+		return !inside
+	})
+	return intersects, inside
+}
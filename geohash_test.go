@@ -0,0 +1,99 @@
+package geobin
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/tile38/geojson/geohash"
+)
+
+// cellPositions returns the corners, center, and some random positions of a
+// geohash cell, slightly inset.
+func cellPositions(rnd *rand.Rand, cell string) []Position {
+	swLat, swLon, neLat, neLon, _ := geohash.Bounds(cell)
+	at := func(fx, fy float64) Position {
+		fx, fy = 0.001+fx*0.998, 0.001+fy*0.998
+		return P(swLon+(neLon-swLon)*fx, swLat+(neLat-swLat)*fy)
+	}
+	ps := []Position{at(0, 0), at(1, 0), at(1, 1), at(0, 1), at(0.5, 0.5)}
+	for i := 0; i < 20; i++ {
+		ps = append(ps, at(rnd.Float64(), rnd.Float64()))
+	}
+	return ps
+}
+
+func TestGeohashCover(t *testing.T) {
+	point := Make2DPoint(-112.0747, 33.4484)
+	hash, _ := GeohashEncode(33.4484, -112.0747, 6)
+	assert.Equal(t, []string{hash}, point.GeohashCover(1, 6, 0))
+	assert.Equal(t, []string{hash[:3]}, point.GeohashCover(3, 3, 0))
+
+	// invalid
+	assert.Nil(t, point.GeohashCover(0, 6, 0))
+	assert.Nil(t, point.GeohashCover(4, 3, 0))
+	assert.Nil(t, point.GeohashCover(1, 13, 0))
+	assert.Nil(t, MakeString("hello").GeohashCover(1, 6, 0))
+	assert.Nil(t, ParseJSON(`{"type":"LineString","coordinates":[]}`).GeohashCover(1, 6, 0))
+
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	polys := []string{
+		`{"type":"Polygon","coordinates":[[[-112.1,33.4],[-111.9,33.4],[-111.9,33.6],[-112.1,33.6],[-112.1,33.4]],
+			[[-112.05,33.45],[-111.95,33.45],[-111.95,33.55],[-112.05,33.55],[-112.05,33.45]]]}`,
+		`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[10,10],[12,10],[11,12],[10,10]]]},"properties":null}`,
+		`{"type":"GeometryCollection","geometries":[{"type":"LineString","coordinates":[[-5,-5],[-4,-3]]},
+			{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}]}`,
+	}
+	for _, json := range polys {
+		o := ParseJSON(json)
+		for _, maxCells := range []int{0, 8, 32, 100} {
+			cover := o.GeohashCover(1, 5, maxCells)
+			assert.True(t, sort.StringsAreSorted(cover))
+			if maxCells > 0 {
+				assert.LessOrEqual(t, len(cover), maxCells, "%s %d", json, maxCells)
+			}
+			// every position is in a cell
+			o.ForEachPosition(func(p Position) bool {
+				hash, _ := GeohashEncode(p.Y, p.X, 12)
+				var found bool
+				for _, cell := range cover {
+					found = found || strings.HasPrefix(hash, cell)
+				}
+				assert.True(t, found, "seed %d %s %v", seed, json, p)
+				return true
+			})
+			interior := o.GeohashInterior(1, 5, maxCells)
+			assert.True(t, sort.StringsAreSorted(interior))
+			for _, cell := range interior {
+				for _, p := range cellPositions(rnd, cell) {
+					assert.Equal(t, 0.0, o.DistanceTo(p), "seed %d %s %s %v", seed, json, cell, p)
+				}
+			}
+		}
+	}
+
+	// a polygon with a hole has no interior cells in the hole
+	o := ParseJSON(polys[0])
+	interior := o.GeohashInterior(1, 6, 0)
+	assert.NotEmpty(t, interior)
+	hole, _ := GeohashEncode(33.5, -112, 6)
+	for _, cell := range interior {
+		assert.False(t, strings.HasPrefix(hole, cell))
+	}
+	// the cells of the interior are in the cover
+	cover := o.GeohashCover(1, 6, 0)
+	for _, cell := range interior {
+		assert.Contains(t, cover, cell)
+	}
+
+	// the min precision wins over the max cells
+	cover = Make2DRect(-10, -10, 10, 10).GeohashCover(2, 4, 1)
+	assert.Greater(t, len(cover), 1)
+	for _, cell := range cover {
+		assert.Len(t, cell, 2)
+	}
+}
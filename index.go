@@ -0,0 +1,300 @@
+package geobin
+
+import (
+	"math"
+	"sort"
+)
+
+const indexMaxEntries = 16
+
+// Index is an in-memory R-tree of objects keyed by id. The zero value is an
+// empty index that is ready to use. Objects are indexed by the X and Y of the
+// bbox that prefixes their data, and the candidates of a search are refined
+// with the exact predicate. An Index is not safe for concurrent writes.
+type Index struct {
+	root  *indexNode
+	items map[string]*indexItem
+}
+
+type indexRect struct {
+	min, max [2]float64
+	wraps    bool // an arc crosses the antimeridian
+}
+
+type indexItem struct {
+	rect indexRect
+	id   string
+	obj  Object
+}
+
+type indexNode struct {
+	rect     indexRect
+	leaf     bool
+	children []*indexNode // branch only
+	items    []*indexItem // leaf only
+}
+
+// objectRect returns the rect of an object from its packed bbox. Returns
+// false for objects that are not geometries or that have no positions.
+func objectRect(o Object) (indexRect, bool) {
+	if !o.IsGeometry() || !o.valid() {
+		return indexRect{}, false
+	}
+	min, max := o.Rect(nil)
+	r := indexRect{min: [2]float64{min[0], min[1]}, max: [2]float64{max[0], max[1]},
+		wraps: objectWraps(o)}
+	return r, r.min[0] <= r.max[0] && r.min[1] <= r.max[1]
+}
+
+func bboxRect(bbox BBox) indexRect {
+	return indexRect{
+		min: [2]float64{bbox.Min.X, bbox.Min.Y},
+		max: [2]float64{bbox.Max.X, bbox.Max.Y},
+	}
+}
+
+func (r indexRect) intersects(b indexRect) bool {
+	return r.min[0] <= b.max[0] && r.max[0] >= b.min[0] &&
+		r.min[1] <= b.max[1] && r.max[1] >= b.min[1]
+}
+
+func (r indexRect) contains(b indexRect) bool {
+	return r.min[0] <= b.min[0] && r.max[0] >= b.max[0] &&
+		r.min[1] <= b.min[1] && r.max[1] >= b.max[1]
+}
+
+func (r indexRect) union(b indexRect) indexRect {
+	return indexRect{
+		min:   [2]float64{math.Min(r.min[0], b.min[0]), math.Min(r.min[1], b.min[1])},
+		max:   [2]float64{math.Max(r.max[0], b.max[0]), math.Max(r.max[1], b.max[1])},
+		wraps: r.wraps || b.wraps,
+	}
+}
+
+func (r indexRect) area() float64 {
+	return (r.max[0] - r.min[0]) * (r.max[1] - r.min[1])
+}
+
+// Len returns the number of objects in the index.
+func (idx *Index) Len() int {
+	return len(idx.items)
+}
+
+// Insert adds the object with the id, replacing any object that already
+// has the id. Objects that are not geometries, or that have no positions,
+// are not indexed.
+func (idx *Index) Insert(id string, o Object) {
+	idx.Delete(id)
+	rect, ok := objectRect(o)
+	if !ok {
+		return
+	}
+	item := &indexItem{rect: rect, id: id, obj: o}
+	if idx.items == nil {
+		idx.items = make(map[string]*indexItem)
+	}
+	idx.items[id] = item
+	if idx.root == nil {
+		idx.root = &indexNode{rect: rect, leaf: true}
+	}
+	if split := idx.root.insert(item); split != nil {
+		root := &indexNode{children: []*indexNode{idx.root, split}}
+		root.recalc()
+		idx.root = root
+	}
+}
+
+// Delete removes the object with the id. Returns false if the id was not
+// found.
+func (idx *Index) Delete(id string) bool {
+	item, ok := idx.items[id]
+	if !ok {
+		return false
+	}
+	delete(idx.items, id)
+	idx.root.delete(item)
+	for !idx.root.leaf && len(idx.root.children) == 1 {
+		idx.root = idx.root.children[0]
+	}
+	if idx.root.len() == 0 {
+		idx.root = nil
+	}
+	return true
+}
+
+// SearchBBox iterates over the objects that intersect the bbox. Return false
+// from iter to stop.
+func (idx *Index) SearchBBox(bbox BBox, iter func(id string, o Object) bool) {
+	idx.search(bboxRect(bbox), false, func(item *indexItem) bool {
+		return !item.obj.IntersectsBBox(bbox) || iter(item.id, item.obj)
+	})
+}
+
+// SearchIntersects iterates over the objects that intersect the target.
+// Return false from iter to stop.
+func (idx *Index) SearchIntersects(target Object, iter func(id string, o Object) bool) {
+	rect, ok := objectRect(target)
+	if !ok {
+		return
+	}
+	idx.search(rect, false, func(item *indexItem) bool {
+		return !item.obj.Intersects(target) || iter(item.id, item.obj)
+	})
+}
+
+// SearchWithin iterates over the objects that are within the target. Return
+// false from iter to stop.
+func (idx *Index) SearchWithin(target Object, iter func(id string, o Object) bool) {
+	rect, ok := objectRect(target)
+	if !ok {
+		return
+	}
+	idx.search(rect, true, func(item *indexItem) bool {
+		return !item.obj.Within(target) || iter(item.id, item.obj)
+	})
+}
+
+// search iterates over the items with a rect that intersects, or is within,
+// the rect.
+func (idx *Index) search(rect indexRect, within bool, iter func(item *indexItem) bool) {
+	if idx.root != nil {
+		idx.root.search(rect, within, iter)
+	}
+}
+
+func (n *indexNode) len() int {
+	if n.leaf {
+		return len(n.items)
+	}
+	return len(n.children)
+}
+
+func (n *indexNode) rectAt(i int) indexRect {
+	if n.leaf {
+		return n.items[i].rect
+	}
+	return n.children[i].rect
+}
+
+// recalc sets the rect of a node that is not empty to the union of its
+// entries.
+func (n *indexNode) recalc() {
+	n.rect = n.rectAt(0)
+	for i := 1; i < n.len(); i++ {
+		n.rect = n.rect.union(n.rectAt(i))
+	}
+}
+
+// insert adds an item to the node. Returns the new sibling when the node is
+// split.
+func (n *indexNode) insert(item *indexItem) *indexNode {
+	if n.len() == 0 {
+		n.rect = item.rect
+	} else {
+		n.rect = n.rect.union(item.rect)
+	}
+	if n.leaf {
+		n.items = append(n.items, item)
+	} else {
+		child := n.children[n.choose(item.rect)]
+		if split := child.insert(item); split != nil {
+			n.children = append(n.children, split)
+		}
+	}
+	if n.len() > indexMaxEntries {
+		return n.split()
+	}
+	return nil
+}
+
+// choose returns the child that needs the least enlargement to include the
+// rect, and then the one with the smallest area.
+func (n *indexNode) choose(rect indexRect) int {
+	var j int
+	var jenlarge, jarea float64
+	for i, child := range n.children {
+		area := child.rect.area()
+		enlarge := child.rect.union(rect).area() - area
+		if i == 0 || enlarge < jenlarge || (enlarge == jenlarge && area < jarea) {
+			j, jenlarge, jarea = i, enlarge, area
+		}
+	}
+	return j
+}
+
+// split moves the upper half of the entries, along the longest axis, into a
+// new sibling.
+func (n *indexNode) split() *indexNode {
+	axis := 0
+	if n.rect.max[1]-n.rect.min[1] > n.rect.max[0]-n.rect.min[0] {
+		axis = 1
+	}
+	sibling := &indexNode{leaf: n.leaf}
+	half := n.len() / 2
+	if n.leaf {
+		sort.Slice(n.items, func(i, j int) bool {
+			return n.items[i].rect.min[axis] < n.items[j].rect.min[axis]
+		})
+		sibling.items = append(sibling.items, n.items[half:]...)
+		n.items = n.items[:half:half]
+	} else {
+		sort.Slice(n.children, func(i, j int) bool {
+			return n.children[i].rect.min[axis] < n.children[j].rect.min[axis]
+		})
+		sibling.children = append(sibling.children, n.children[half:]...)
+		n.children = n.children[:half:half]
+	}
+	n.recalc()
+	sibling.recalc()
+	return sibling
+}
+
+// delete removes an item from the node. Empty children are removed.
+func (n *indexNode) delete(item *indexItem) bool {
+	if n.leaf {
+		for i, it := range n.items {
+			if it == item {
+				n.items = append(n.items[:i], n.items[i+1:]...)
+				if len(n.items) > 0 {
+					n.recalc()
+				}
+				return true
+			}
+		}
+		return false
+	}
+	for i, child := range n.children {
+		if !child.rect.contains(item.rect) || !child.delete(item) {
+			continue
+		}
+		if child.len() == 0 {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+		}
+		if len(n.children) > 0 {
+			n.recalc()
+		}
+		return true
+	}
+	return false
+}
+
+func (n *indexNode) search(rect indexRect, within bool, iter func(item *indexItem) bool) bool {
+	if n.leaf {
+		for _, item := range n.items {
+			if within && !rect.contains(item.rect) ||
+				!within && !rect.intersects(item.rect) {
+				continue
+			}
+			if !iter(item) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, child := range n.children {
+		if rect.intersects(child.rect) && !child.search(rect, within, iter) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package geobin
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collectIndex(search func(iter func(id string, o Object) bool)) []string {
+	var ids []string
+	search(func(id string, o Object) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+	return ids
+}
+
+func TestIndex(t *testing.T) {
+	var idx Index
+	assert.Equal(t, 0, idx.Len())
+	assert.False(t, idx.Delete("a"))
+	idx.SearchBBox(BBox{Max: P(10, 10)}, func(id string, o Object) bool {
+		t.Fatal("unexpected")
+		return true
+	})
+
+	idx.Insert("point", Make2DPoint(1, 1))
+	idx.Insert("line", ParseJSON(`{"type":"LineString","coordinates":[[0,5],[5,0]]}`))
+	idx.Insert("poly", ParseJSON(`{"type":"Polygon","coordinates":[[[10,10],[20,10],[20,20],[10,20],[10,10]]]}`))
+	idx.Insert("string", MakeString("hello"))
+	idx.Insert("empty", ParseJSON(`{"type":"LineString","coordinates":[]}`))
+	assert.Equal(t, 3, idx.Len())
+
+	// the bbox of the line overlaps, but not the line itself
+	search := func(bbox BBox) []string {
+		return collectIndex(func(iter func(id string, o Object) bool) {
+			idx.SearchBBox(bbox, iter)
+		})
+	}
+	assert.Equal(t, []string{"point"}, search(BBox{Min: P(0, 0), Max: P(1, 1)}))
+	assert.Equal(t, []string{"line", "point"}, search(BBox{Min: P(0, 0), Max: P(3, 3)}))
+	assert.Equal(t, []string{"poly"}, search(BBox{Min: P(15, 15), Max: P(30, 30)}))
+
+	target := ParseJSON(`{"type":"Polygon","coordinates":[[[0,0],[12,0],[12,12],[0,12],[0,0]]]}`)
+	assert.Equal(t, []string{"line", "point", "poly"}, collectIndex(func(iter func(id string, o Object) bool) {
+		idx.SearchIntersects(target, iter)
+	}))
+	assert.Equal(t, []string{"line", "point"}, collectIndex(func(iter func(id string, o Object) bool) {
+		idx.SearchWithin(target, iter)
+	}))
+
+	// replace
+	idx.Insert("point", Make2DPoint(50, 50))
+	assert.Equal(t, 3, idx.Len())
+	assert.Equal(t, []string{"line"}, search(BBox{Min: P(0, 0), Max: P(3, 3)}))
+	assert.Equal(t, []string{"point"}, search(BBox{Min: P(40, 40), Max: P(60, 60)}))
+
+	// stop early
+	var n int
+	idx.SearchBBox(BBox{Min: P(-100, -100), Max: P(100, 100)}, func(id string, o Object) bool {
+		n++
+		return false
+	})
+	assert.Equal(t, 1, n)
+
+	assert.True(t, idx.Delete("point"))
+	assert.True(t, idx.Delete("line"))
+	assert.True(t, idx.Delete("poly"))
+	assert.False(t, idx.Delete("poly"))
+	assert.Equal(t, 0, idx.Len())
+	assert.Nil(t, idx.root)
+}
+
+func TestIndexRandom(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	var idx Index
+	objs := make(map[string]Object)
+	randRect := func(size float64) BBox {
+		x, y := rnd.Float64()*200-100, rnd.Float64()*200-100
+		return BBox{Min: P(x, y), Max: P(x+rnd.Float64()*size, y+rnd.Float64()*size)}
+	}
+	check := func() {
+		assert.Equal(t, len(objs), idx.Len(), "seed %d", seed)
+		for i := 0; i < 20; i++ {
+			bbox := randRect(50)
+			var expect []string
+			for id, o := range objs {
+				if o.IntersectsBBox(bbox) {
+					expect = append(expect, id)
+				}
+			}
+			sort.Strings(expect)
+			actual := collectIndex(func(iter func(id string, o Object) bool) {
+				idx.SearchBBox(bbox, iter)
+			})
+			assert.Equal(t, expect, actual, "seed %d", seed)
+		}
+	}
+	for i := 0; i < 2000; i++ {
+		id := strconv.Itoa(rnd.Intn(1500))
+		var o Object
+		if rnd.Intn(2) == 0 {
+			p := randRect(0).Min
+			o = Make2DPoint(p.X, p.Y)
+		} else {
+			r := randRect(10)
+			o = Make2DRect(r.Min.X, r.Min.Y, r.Max.X, r.Max.Y)
+		}
+		idx.Insert(id, o)
+		objs[id] = o
+	}
+	check()
+	for id := range objs {
+		if rnd.Intn(3) > 0 {
+			assert.True(t, idx.Delete(id))
+			delete(objs, id)
+		}
+	}
+	check()
+	for id := range objs {
+		idx.Delete(id)
+		delete(objs, id)
+	}
+	check()
+	assert.Nil(t, idx.root)
+}
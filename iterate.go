@@ -0,0 +1,292 @@
+package geobin
+
+import "encoding/binary"
+
+// RingReader reads the positions of a ring, or a line, directly from the
+// packed data.
+type RingReader struct {
+	data []byte
+	dims int
+	n    int
+	rect int // 1 for a 2D rect, 2-7 for the faces of a 3D rect
+}
+
+// rectRings are the corners, 0 = min and 1 = max, of the rings that the
+// simple rects are exported as. The first is the 2D rect and the others are
+// the bottom, north, south, west, east, and top faces of the 3D rect.
+var rectRings = [7][5][3]byte{
+	{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}},
+	{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0, 0, 0}},
+	{{0, 1, 0}, {1, 1, 0}, {1, 1, 1}, {0, 1, 1}, {0, 1, 0}},
+	{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {0, 0, 1}, {0, 0, 0}},
+	{{0, 0, 0}, {0, 1, 0}, {0, 1, 1}, {0, 0, 1}, {0, 0, 0}},
+	{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {1, 0, 1}, {1, 0, 0}},
+	{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}, {0, 0, 1}},
+}
+
+// Len returns the number of positions.
+func (r RingReader) Len() int {
+	return r.n
+}
+
+// Dims returns the number of dimensions, 2 or 3.
+func (r RingReader) Dims() int {
+	return r.dims
+}
+
+// At returns the position at index i.
+func (r RingReader) At(i int) Position {
+	if r.rect == 0 {
+		p, _ := readPosition(r.data[i*r.dims*8:], r.dims)
+		return p
+	}
+	var vals [3]float64
+	corners := rectRings[r.rect-1][i]
+	for j := 0; j < r.dims; j++ {
+		off := (int(corners[j])*r.dims + j) * 8
+		vals[j], _ = readFloat64(r.data[off:])
+	}
+	return Position{vals[0], vals[1], vals[2]}
+}
+
+// ForEachPosition iterates over each position. Return false from the
+// iterator to stop.
+func (r RingReader) ForEachPosition(iter func(p Position) bool) bool {
+	for i := 0; i < r.n; i++ {
+		if !iter(r.At(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// readRing reads a [UINT32][POSITION...] block. Returns false if the data is
+// malformed.
+func readRing(data []byte, dims int) (RingReader, []byte, bool) {
+	if len(data) < 4 {
+		return RingReader{}, nil, false
+	}
+	n, data := readUint32(data)
+	if n > len(data)/(dims*8) {
+		return RingReader{}, nil, false
+	}
+	sz := n * dims * 8
+	return RingReader{data: data[:sz:sz], dims: dims, n: n}, data[sz:], true
+}
+
+// readCount reads a [UINT32] count. Returns false if the data is malformed.
+func readCount(data []byte) (int, []byte, bool) {
+	if len(data) < 4 {
+		return 0, nil, false
+	}
+	n, data := readUint32(data)
+	return n, data, true
+}
+
+// ForEachPosition iterates over every position in the geometry, including
+// the children of collections and features. Simple rects produce the
+// positions of the polygons that they are exported as. Return false from
+// the iterator to stop. Malformed data also stops the iteration.
+func (g Geometry) ForEachPosition(iter func(p Position) bool) {
+	g.forEachPosition(iter)
+}
+
+func (g Geometry) forEachPosition(iter func(p Position) bool) bool {
+	if g.Dims != 2 && g.Dims != 3 {
+		return true
+	}
+	switch g.Type {
+	case Point:
+		if len(g.Data) < g.Dims*8 {
+			return false
+		}
+		p, _ := readPosition(g.Data, g.Dims)
+		return iter(p)
+	case MultiPoint:
+		r, _, ok := readRing(g.Data, g.Dims)
+		return ok && r.ForEachPosition(iter)
+	case Feature, GeometryCollection, FeatureCollection:
+		return g.forEachChild(func(child Geometry) bool {
+			return child.forEachPosition(iter)
+		})
+	}
+	return g.forEachRing(func(r RingReader) bool {
+		return r.ForEachPosition(iter)
+	})
+}
+
+// ForEachRing iterates over the lines and rings in the geometry, including
+// the children of collections and features. That is each LineString, the
+// lines of a MultiLineString, and the rings of the polygons. Points are
+// skipped. Return false from the iterator to stop.
+func (g Geometry) ForEachRing(iter func(ring RingReader) bool) {
+	g.forEachRing(iter)
+}
+
+func (g Geometry) forEachRing(iter func(ring RingReader) bool) bool {
+	if g.Dims != 2 && g.Dims != 3 {
+		return true
+	}
+	if g.Simple {
+		switch g.Type {
+		case Polygon:
+			if len(g.Data) < 32 {
+				return false
+			}
+			return iter(RingReader{data: g.Data, dims: 2, n: 5, rect: 1})
+		case MultiPolygon:
+			if len(g.Data) < 48 {
+				return false
+			}
+			for i := 2; i <= 7; i++ {
+				if !iter(RingReader{data: g.Data, dims: 3, n: 5, rect: i}) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	switch g.Type {
+	case LineString:
+		r, _, ok := readRing(g.Data, g.Dims)
+		return ok && iter(r)
+	case MultiLineString, Polygon:
+		_, ok := forEachRingIn(g.Data, g.Dims, iter)
+		return ok
+	case MultiPolygon:
+		n, data, ok := readCount(g.Data)
+		for i := 0; i < n && ok; i++ {
+			data, ok = forEachRingIn(data, g.Dims, iter)
+		}
+		return ok
+	case Feature, GeometryCollection, FeatureCollection:
+		return g.forEachChild(func(child Geometry) bool {
+			return child.forEachRing(iter)
+		})
+	}
+	return true
+}
+
+// forEachRingIn iterates over a [UINT32][RING...] block and returns the
+// remaining data. Returns false if the iterator stopped or the data is
+// malformed.
+func forEachRingIn(data []byte, dims int, iter func(ring RingReader) bool) ([]byte, bool) {
+	n, data, ok := readCount(data)
+	for i := 0; i < n && ok; i++ {
+		var r RingReader
+		if r, data, ok = readRing(data, dims); ok {
+			ok = iter(r)
+		}
+	}
+	return data, ok
+}
+
+// ForEachPart iterates over the single parts of the geometry. That is each
+// Point, LineString, and Polygon of the multi types and of the children of
+// collections and features. The part data is a sub-slice of the geometry.
+// Simple objects are a single part. Return false from the iterator to stop.
+func (g Geometry) ForEachPart(iter func(part Geometry) bool) {
+	g.forEachPart(iter)
+}
+
+func (g Geometry) forEachPart(iter func(part Geometry) bool) bool {
+	if g.Dims != 2 && g.Dims != 3 {
+		return true
+	}
+	if g.Simple {
+		return iter(g)
+	}
+	part := Geometry{Dims: g.Dims}
+	switch g.Type {
+	case Point, LineString, Polygon:
+		return iter(g)
+	case MultiPoint:
+		r, _, ok := readRing(g.Data, g.Dims)
+		part.Type = Point
+		sz := g.Dims * 8
+		for i := 0; i < r.n && ok; i++ {
+			part.Data = r.data[i*sz : (i+1)*sz : (i+1)*sz]
+			ok = iter(part)
+		}
+		return ok
+	case MultiLineString:
+		n, data, ok := readCount(g.Data)
+		part.Type = LineString
+		for i := 0; i < n && ok; i++ {
+			start := data
+			if _, data, ok = readRing(data, g.Dims); !ok {
+				return false
+			}
+			part.Data = start[: len(start)-len(data) : len(start)-len(data)]
+			ok = iter(part)
+		}
+		return ok
+	case MultiPolygon:
+		n, data, ok := readCount(g.Data)
+		part.Type = Polygon
+		for i := 0; i < n && ok; i++ {
+			start := data
+			if data, ok = forEachRingIn(data, g.Dims, func(RingReader) bool {
+				return true
+			}); !ok {
+				return false
+			}
+			part.Data = start[: len(start)-len(data) : len(start)-len(data)]
+			ok = iter(part)
+		}
+		return ok
+	case Feature, GeometryCollection, FeatureCollection:
+		return g.forEachChild(func(child Geometry) bool {
+			return child.forEachPart(iter)
+		})
+	}
+	return true
+}
+
+// forEachChild iterates over the geometries of the children of a Feature
+// or collection.
+func (g Geometry) forEachChild(iter func(child Geometry) bool) bool {
+	n := 1
+	data := g.Data
+	if g.Type != Feature {
+		var ok bool
+		if n, data, ok = readCount(data); !ok {
+			return false
+		}
+	}
+	for i := 0; i < n; i++ {
+		if len(data) < 4 {
+			return false
+		}
+		sz := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+		if sz > len(data) {
+			return false
+		}
+		if !iter(Object{data[:sz:sz]}.Geometry()) {
+			return false
+		}
+		data = data[sz:]
+	}
+	return true
+}
+
+// ForEachPosition iterates over every position in the object. See
+// Geometry.ForEachPosition. The packed data is read without allocating,
+// except for compacted coordinates, which are expanded into a copy first.
+// See Compact.
+func (o Object) ForEachPosition(iter func(p Position) bool) {
+	o.Geometry().forEachPosition(iter)
+}
+
+// ForEachRing iterates over the lines and rings in the object. See
+// Geometry.ForEachRing. Compacted coordinates are expanded first.
+func (o Object) ForEachRing(iter func(ring RingReader) bool) {
+	o.Geometry().forEachRing(iter)
+}
+
+// ForEachPart iterates over the single parts of the object. See
+// Geometry.ForEachPart. Compacted coordinates are expanded first.
+func (o Object) ForEachPart(iter func(part Geometry) bool) {
+	o.Geometry().forEachPart(iter)
+}
@@ -0,0 +1,140 @@
+package geobin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collectPositions(o Object) []Position {
+	var ps []Position
+	o.ForEachPosition(func(p Position) bool {
+		ps = append(ps, p)
+		return true
+	})
+	return ps
+}
+
+func TestForEachPosition(t *testing.T) {
+	for _, js := range testValidateJSON {
+		o := ParseJSON(js)
+		ps := collectPositions(o)
+		assert.Equal(t, o.PositionCount(), len(ps), js)
+		assert.Equal(t, ps, collectPositions(o.Compact(1e-7)), js)
+	}
+	assert.Equal(t, []Position{P3(1, 2, 3)}, collectPositions(Make3DPoint(1, 2, 3)))
+	assert.Equal(t, []Position{P(1, 2), P(1, 4), P(3, 4), P(3, 2), P(1, 2)},
+		collectPositions(Make2DRect(1, 2, 3, 4)))
+	assert.Equal(t, 30, len(collectPositions(Make3DRect(1, 2, 3, 4, 5, 6))))
+	assert.Equal(t, []Position{P(1, 2), P(3, 4)}, collectPositions(ParseJSON(
+		`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},
+		{"type":"Feature","geometry":{"type":"MultiPoint","coordinates":[[3,4]]}}]}`)))
+	assert.Nil(t, collectPositions(MakeString("hello")))
+
+	// stop early
+	var n int
+	ParseJSON(testPolyHoles).ForEachPosition(func(p Position) bool {
+		n++
+		return n < 7
+	})
+	assert.Equal(t, 7, n)
+}
+
+func TestForEachRing(t *testing.T) {
+	var lens []int
+	ParseJSON(testPolyHoles).ForEachRing(func(r RingReader) bool {
+		lens = append(lens, r.Len())
+		assert.Equal(t, r.At(0), r.At(r.Len()-1))
+		assert.Equal(t, 2, r.Dims())
+		return true
+	})
+	assert.Equal(t, []int{5, 5, 4}, lens)
+
+	// each face of a 3D rect matches the exported multipolygon
+	r3 := Make3DRect(1, 2, 3, 4, 5, 6)
+	var faces [][]Position
+	r3.ForEachRing(func(r RingReader) bool {
+		var face []Position
+		r.ForEachPosition(func(p Position) bool {
+			face = append(face, p)
+			return true
+		})
+		faces = append(faces, face)
+		return true
+	})
+	assert.Equal(t, r3.polySimplePairsFor3DRect(), faces)
+
+	var count int
+	for _, o := range []Object{Make2DPoint(1, 2), ParseJSON(`{"type":"MultiPoint","coordinates":[[1,2]]}`)} {
+		o.ForEachRing(func(r RingReader) bool {
+			count++
+			return true
+		})
+	}
+	assert.Equal(t, 0, count)
+}
+
+func TestForEachPart(t *testing.T) {
+	o := ParseJSON(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[
+			[[[0,0],[0,1],[1,1],[0,0]]],[[[5,5],[5,6],[6,6],[5,5]],[[5.1,5.5],[5.2,5.5],[5.2,5.6],[5.1,5.5]]]]}},
+		{"type":"Feature","geometry":{"type":"MultiLineString","coordinates":[[[1,2],[3,4]],[[5,6],[7,8],[9,10]]]}},
+		{"type":"Feature","geometry":{"type":"MultiPoint","coordinates":[[1,2,3],[4,5,6]]}},
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}
+	]}`)
+	var types []GeometryType
+	var counts []int
+	o.ForEachPart(func(part Geometry) bool {
+		types = append(types, part.Type)
+		counts = append(counts, part.PositionCount())
+		return true
+	})
+	assert.Equal(t, []GeometryType{Polygon, Polygon, LineString, LineString, Point, Point, Point}, types)
+	assert.Equal(t, []int{4, 8, 2, 3, 1, 1, 1}, counts)
+
+	var parts []Geometry
+	Make2DRect(1, 2, 3, 4).ForEachPart(func(part Geometry) bool {
+		parts = append(parts, part)
+		return true
+	})
+	assert.Equal(t, []Geometry{Make2DRect(1, 2, 3, 4).Geometry()}, parts)
+}
+
+func TestForEachMalformed(t *testing.T) {
+	for _, o := range testValidateObjects() {
+		data := o.Binary()
+		for i := 0; i < len(data); i++ {
+			bad := WrapBinary(data[:i])
+			bad.ForEachPosition(func(Position) bool { return true })
+			bad.ForEachRing(func(RingReader) bool { return true })
+			bad.ForEachPart(func(Geometry) bool { return true })
+		}
+	}
+}
+
+func TestForEachAllocs(t *testing.T) {
+	o := ParseJSON(`{"type":"GeometryCollection","geometries":[` + testPolyHoles + `,
+		{"type":"MultiPolygon","coordinates":[[[[0,0],[0,1],[1,1],[0,0]]]]}]}`)
+	var sum float64
+	iterate := func(o Object) {
+		o.ForEachPosition(func(p Position) bool {
+			sum += p.X
+			return true
+		})
+		o.ForEachRing(func(r RingReader) bool {
+			sum += float64(r.Len())
+			return true
+		})
+		o.ForEachPart(func(part Geometry) bool {
+			sum += float64(len(part.Data))
+			return true
+		})
+	}
+	assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() { iterate(o) }))
+
+	// compacted objects are expanded on each call, unless expanded once
+	c := o.Compact(1e-7)
+	assert.NotEqual(t, 0.0, testing.AllocsPerRun(100, func() { iterate(c) }))
+	e := c.Expand()
+	assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() { iterate(e) }))
+}
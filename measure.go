@@ -0,0 +1,119 @@
+package geobin
+
+import (
+	"math"
+
+	"github.com/tidwall/tile38/geojson/geo"
+)
+
+// earthRadius is the radius, in meters, of the sphere that the geodesic
+// measurements use. Same as the one used by Position.DistanceTo.
+const earthRadius = 6371e3
+
+// Length returns the geodesic length, in meters, of the lines in the object.
+// That is each LineString, including the lines of a MultiLineString and of
+// the children of collections and features. Points and polygons have no
+// length, see Perimeter.
+func (o Object) Length() float64 {
+	var length float64
+	o.ForEachPart(func(part Geometry) bool {
+		if part.Type == LineString {
+			if r, _, ok := readRing(part.Data, part.Dims); ok {
+				length += ringLength(r)
+			}
+		}
+		return true
+	})
+	return length
+}
+
+// Perimeter returns the geodesic length, in meters, of all of the rings of
+// the polygons in the object, including the holes.
+func (o Object) Perimeter() float64 {
+	var perimeter float64
+	o.ForEachPart(func(part Geometry) bool {
+		forEachPolygonRing(part, func(_ int, r RingReader) bool {
+			perimeter += ringLength(r)
+			return true
+		})
+		return true
+	})
+	return perimeter
+}
+
+// Area returns the geodesic area, in square meters, of the polygons in the
+// object with the holes subtracted. The area is measured on a sphere. Simple
+// 3D rects are measured by their footprint.
+func (o Object) Area() float64 {
+	var area float64
+	o.ForEachPart(func(part Geometry) bool {
+		var polyArea float64
+		forEachPolygonRing(part, func(i int, r RingReader) bool {
+			if i == 0 {
+				polyArea = math.Abs(ringArea(r))
+			} else {
+				polyArea -= math.Abs(ringArea(r))
+			}
+			return true
+		})
+		area += math.Max(polyArea, 0)
+		return true
+	})
+	return area
+}
+
+// forEachPolygonRing iterates over the rings of a single part when it's a
+// polygon, starting with the exterior ring. A simple 3D rect is the
+// footprint of the rect.
+func forEachPolygonRing(part Geometry, iter func(i int, r RingReader) bool) {
+	if part.Simple {
+		switch {
+		case part.Type == Polygon && len(part.Data) >= 32:
+			iter(0, RingReader{data: part.Data, dims: 2, n: 5, rect: 1})
+		case part.Type == MultiPolygon && len(part.Data) >= 48:
+			iter(0, RingReader{data: part.Data, dims: 3, n: 5, rect: 2})
+		}
+		return
+	}
+	if part.Type != Polygon {
+		return
+	}
+	var i int
+	forEachRingIn(part.Data, part.Dims, func(r RingReader) bool {
+		i++
+		return iter(i-1, r)
+	})
+}
+
+// ringLength returns the geodesic length of a line, in meters.
+func ringLength(r RingReader) float64 {
+	var length float64
+	for i := 1; i < r.Len(); i++ {
+		a, b := r.At(i-1), r.At(i)
+		length += geo.DistanceTo(a.Y, a.X, b.Y, b.X)
+	}
+	return length
+}
+
+// ringArea returns the signed geodesic area of a ring, in square meters,
+// using the spherical excess from "Some Algorithms for Polygons on a
+// Sphere" by Chamberlain and Duquette. Positive is clockwise.
+func ringArea(r RingReader) float64 {
+	n := r.Len()
+	if n < 3 {
+		return 0
+	}
+	var area float64
+	a := r.At(n - 1)
+	for i := 0; i < n; i++ {
+		b := r.At(i)
+		area += toRadians(b.X-a.X) *
+			(2 + math.Sin(toRadians(a.Y)) + math.Sin(toRadians(b.Y)))
+		a = b
+	}
+	return area * earthRadius * earthRadius / 2
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
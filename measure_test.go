@@ -0,0 +1,67 @@
+package geobin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLength(t *testing.T) {
+	line := ParseJSON(`{"type":"LineString","coordinates":[[0,0],[1,0],[1,1]]}`)
+	expect := P(0, 0).DistanceTo(P(1, 0)) + P(1, 0).DistanceTo(P(1, 1))
+	assert.InDelta(t, expect, line.Length(), 1e-6)
+	assert.InDelta(t, 222390, line.Length(), 1)
+	assert.InDelta(t, expect, line.Compact(1e-7).Length(), 1e-6)
+	assert.InDelta(t, expect*2, ParseJSON(`{"type":"MultiLineString","coordinates":[
+		[[0,0],[1,0],[1,1]],[[0,0],[1,0],[1,1]]]}`).Length(), 1e-6)
+	assert.InDelta(t, expect+P(0, 0).DistanceTo(P(0, 0.01)), ParseJSON(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":`+line.JSON()+`},
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}},
+		{"type":"Feature","geometry":{"type":"LineString","coordinates":[[0,0],[0,0.01]]}}
+	]}`).Length(), 1e-6)
+	assert.Equal(t, 0.0, ParseJSON(testPolyHoles).Length())
+	assert.Equal(t, 0.0, Make2DPoint(1, 2).Length())
+	assert.Equal(t, 0.0, MakeString("hello").Length())
+}
+
+func TestArea(t *testing.T) {
+	// a one degree square at the equator is R^2 * rad(1) * sin(1)
+	deg := math.Pi / 180
+	cell := earthRadius * earthRadius * deg * math.Sin(deg)
+	square := `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`
+	assert.InDelta(t, cell, ParseJSON(square).Area(), 1e-3)
+	assert.InDelta(t, 12363683990, ParseJSON(square).Area(), 1)
+	// winding does not matter
+	assert.InDelta(t, cell, ParseJSON(`{"type":"Polygon","coordinates":[[[0,0],[0,1],[1,1],[1,0],[0,0]]]}`).Area(), 1e-3)
+	assert.InDelta(t, cell, Make2DRect(0, 0, 1, 1).Area(), 1e-3)
+	assert.InDelta(t, cell, Make3DRect(0, 0, 5, 1, 1, 10).Area(), 1e-3)
+	// holes are subtracted
+	holed := ParseJSON(`{"type":"MultiPolygon","coordinates":[
+		[[[0,0],[2,0],[2,2],[0,2],[0,0]],[[0,0],[0,1],[1,1],[1,0],[0,0]]],
+		[[[10,0],[11,0],[11,1],[10,1],[10,0]]]]}`)
+	big := ParseJSON(`{"type":"Polygon","coordinates":[[[0,0],[2,0],[2,2],[0,2],[0,0]]]}`).Area()
+	assert.InDelta(t, big, holed.Area(), 1e-3)
+	assert.InDelta(t, big, ParseJSON(`{"type":"Feature","geometry":`+holed.JSON()+`}`).Area(), 1e-3)
+	assert.Equal(t, 0.0, ParseJSON(`{"type":"LineString","coordinates":[[0,0],[1,0],[1,1]]}`).Area())
+}
+
+func TestPerimeter(t *testing.T) {
+	square := ParseJSON(`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`)
+	expect := P(0, 0).DistanceTo(P(1, 0)) + P(1, 0).DistanceTo(P(1, 1)) +
+		P(1, 1).DistanceTo(P(0, 1)) + P(0, 1).DistanceTo(P(0, 0))
+	assert.InDelta(t, expect, square.Perimeter(), 1e-6)
+	assert.InDelta(t, expect, Make2DRect(0, 0, 1, 1).Perimeter(), 1e-6)
+	assert.InDelta(t, expect, Make3DRect(0, 0, 5, 1, 1, 10).Perimeter(), 1e-6)
+	assert.InDelta(t, expect*2, ParseJSON(`{"type":"Polygon","coordinates":[
+		[[0,0],[1,0],[1,1],[0,1],[0,0]],[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`).Perimeter(), 1e-6)
+	assert.Equal(t, 0.0, ParseJSON(`{"type":"LineString","coordinates":[[0,0],[1,0],[1,1]]}`).Perimeter())
+}
+
+func TestMeasureTruncated(t *testing.T) {
+	// simple rects without their bbox
+	for _, data := range [][]byte{{1, 5}, {1, 7}} {
+		assert.Equal(t, 0.0, WrapBinary(data).Area())
+		assert.Equal(t, 0.0, WrapBinary(data).Perimeter())
+	}
+}
@@ -0,0 +1,254 @@
+package geobin
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	errNotFeature  = errors.New("not a feature")
+	errInvalidPath = errors.New("invalid path")
+)
+
+// ID returns the "id" member of a Feature.
+func (o Object) ID() gjson.Result {
+	return gjson.GetBytes(o.Members(), "id")
+}
+
+// Property returns the value at the path in the "properties" member of a
+// Feature. The path uses the gjson syntax.
+func (o Object) Property(path string) gjson.Result {
+	return gjson.GetBytes(o.Members(), "properties").Get(path)
+}
+
+// SetID returns a copy of the Feature with the "id" member set to the value,
+// which is encoded with json.Marshal. The bbox, geometry, and exdata are
+// kept. The original object is not altered.
+func (o Object) SetID(value interface{}) (Object, error) {
+	return o.setMember([]string{"id"}, value, false)
+}
+
+// SetProperty returns a copy of the Feature with the value set at the path in
+// the "properties" member. The value is encoded with json.Marshal. The path
+// uses the gjson syntax of Property, such as "name.first" or "tags.0", where
+// a number is an index into an array and a dot in a key is escaped with a
+// backslash. An index of the length of an array appends to it. Wildcards,
+// queries, modifiers, and pipes are not allowed. Missing objects are created
+// along the way. The bbox, geometry, and exdata are kept. The original
+// object is not altered.
+func (o Object) SetProperty(path string, value interface{}) (Object, error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return Object{}, err
+	}
+	return o.setMember(append([]string{"properties"}, keys...), value, false)
+}
+
+// DeleteProperty returns a copy of the Feature with the value at the path in
+// the "properties" member removed. See SetProperty for the path syntax. The
+// original object is returned if the path does not exist.
+func (o Object) DeleteProperty(path string) (Object, error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return Object{}, err
+	}
+	return o.setMember(append([]string{"properties"}, keys...), nil, true)
+}
+
+// splitPath splits a gjson path into keys. Paths that match more than one
+// value, or that are not plain keys, are invalid.
+func splitPath(path string) ([]string, error) {
+	var keys []string
+	var key []byte
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '\\':
+			if i == len(path)-1 {
+				return nil, errInvalidPath
+			}
+			i++
+			key = append(key, path[i])
+		case c == '.':
+			keys = append(keys, string(key))
+			key = key[:0]
+		case c == '*' || c == '?' || c == '#' || c == '|' ||
+			len(key) == 0 && (c == '@' || c == '[' || c == '{'):
+			return nil, errInvalidPath
+		default:
+			key = append(key, c)
+		}
+	}
+	keys = append(keys, string(key))
+	for _, key := range keys {
+		if key == "" {
+			return nil, errInvalidPath
+		}
+	}
+	return keys, nil
+}
+
+func (o Object) setMember(keys []string, value interface{}, del bool) (Object, error) {
+	if o.GeometryType() != Feature || o.Geometry().Simple || !o.valid() {
+		return Object{}, errNotFeature
+	}
+	var raw []byte
+	if !del {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return Object{}, err
+		}
+	}
+	members, found, err := setJSONMember(o.Members(), keys, raw, del)
+	if err != nil {
+		return Object{}, err
+	}
+	if del && !found {
+		return o, nil
+	}
+	return o.setMembers(members), nil
+}
+
+// setJSONMember sets, or deletes, the value at the keys in a JSON object or
+// array. A missing or null object is treated as empty. Returns false if the
+// keys were not found.
+func setJSONMember(obj []byte, keys []string, value []byte, del bool) ([]byte, bool, error) {
+	res := gjson.ParseBytes(obj)
+	if res.IsArray() {
+		return setJSONElement(res.Array(), keys, value, del)
+	}
+	if !res.IsObject() {
+		if del {
+			return nil, false, nil
+		}
+		if res.Type != gjson.Null {
+			return nil, false, errInvalidPath
+		}
+		res = gjson.Result{}
+	}
+	var out []byte
+	var matched, found bool
+	var err error
+	out = append(out, '{')
+	res.ForEach(func(key, val gjson.Result) bool {
+		raw := []byte(val.Raw)
+		if key.String() == keys[0] && !matched {
+			matched = true
+			if len(keys) > 1 {
+				raw, found, err = setJSONMember(raw, keys[1:], value, del)
+				if err != nil || (del && !found) {
+					return false
+				}
+			} else if del {
+				found = true
+				return true
+			} else {
+				raw = value
+			}
+		}
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = append(out, key.Raw...)
+		out = append(out, ':')
+		out = append(out, raw...)
+		return true
+	})
+	if err != nil || (del && !found) {
+		return nil, found, err
+	}
+	if !matched {
+		raw := value
+		if len(keys) > 1 {
+			raw, _, _ = setJSONMember(nil, keys[1:], value, false)
+		}
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = appendJSONStringBytes(out, []byte(keys[0]))
+		out = append(out, ':')
+		out = append(out, raw...)
+	}
+	return append(out, '}'), found, nil
+}
+
+// setJSONElement sets, or deletes, the value at the keys in the elements of
+// a JSON array, where the first key is an index. An index of the length of
+// the array appends the value. Returns false if the keys were not found.
+func setJSONElement(elems []gjson.Result, keys []string, value []byte, del bool) ([]byte, bool, error) {
+	index, ok := arrayIndex(keys[0])
+	if !ok || index > len(elems) || del && index == len(elems) {
+		if del {
+			return nil, false, nil
+		}
+		return nil, false, errInvalidPath
+	}
+	var found bool
+	out := []byte{'['}
+	for i := 0; i < len(elems) || i == index; i++ {
+		var raw []byte
+		if i < len(elems) {
+			raw = []byte(elems[i].Raw)
+		}
+		if i == index {
+			if len(keys) > 1 {
+				var err error
+				raw, found, err = setJSONMember(raw, keys[1:], value, del)
+				if err != nil || del && !found {
+					return nil, found, err
+				}
+			} else if del {
+				found = true
+				continue
+			} else {
+				raw = value
+			}
+		}
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = append(out, raw...)
+	}
+	return append(out, ']'), found, nil
+}
+
+// arrayIndex returns the array index of a key that is only digits.
+func arrayIndex(key string) (int, bool) {
+	for i := 0; i < len(key); i++ {
+		if key[i] < '0' || key[i] > '9' {
+			return 0, false
+		}
+	}
+	index, err := strconv.Atoi(key)
+	return index, err == nil
+}
+
+// setMembers returns a copy of a valid complex object with the Members
+// component replaced. Empty members, or "{}", are removed.
+func (o Object) setMembers(members []byte) Object {
+	if strings.TrimSpace(string(members)) == "{}" {
+		members = nil
+	}
+	c := o.parseComponents()
+	head := c.data[0]
+	geom := c.data[1:]
+	if head&1 == 1 {
+		sz, data := readUint32(geom)
+		geom = data[sz:]
+	}
+	data := make([]byte, 0, 5+len(members)+len(geom))
+	if len(members) > 0 {
+		data = append(data, head|1, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(data[1:], uint32(len(members)))
+		data = append(data, members...)
+	} else {
+		data = append(data, head&^1)
+	}
+	c.data = append(data, geom...)
+	return c.reconstructObject()
+}
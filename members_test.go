@@ -0,0 +1,117 @@
+package geobin
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembers(t *testing.T) {
+	o := ParseJSON(`{"type":"Feature","id":"abc","bbox":[0,0,10,10],
+		"properties":{"name":{"first":"Tom"},"age":37},
+		"geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]}}`).SetExData([]byte("extra"))
+	assert.Equal(t, "abc", o.ID().String())
+	assert.Equal(t, "Tom", o.Property("name.first").String())
+	assert.Equal(t, int64(37), o.Property("age").Int())
+	assert.False(t, o.Property("missing").Exists())
+
+	o2, err := o.SetID(123)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(123), o2.ID().Int())
+	o2, err = o2.SetProperty("name.last", "Anderson")
+	assert.NoError(t, err)
+	o2, err = o2.SetProperty("tags.a\\.b", []int{1, 2})
+	assert.NoError(t, err)
+	o2, err = o2.SetProperty("age", json.RawMessage(`38`))
+	assert.NoError(t, err)
+	o2, err = o2.DeleteProperty("name.first")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"Feature","bbox":[0,0,10,10],"geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]},`+
+		`"id":123,"properties":{"name":{"last":"Anderson"},"age":38,"tags":{"a.b":[1,2]}}}`, o2.JSON())
+	assert.NoError(t, Validate(o2.Binary()))
+	assert.Equal(t, []byte("extra"), o2.ExData())
+	assert.Equal(t, o.BBox(), o2.BBox())
+
+	// the original is not altered
+	assert.Equal(t, "abc", o.ID().String())
+
+	// deleting a missing path returns the original
+	o3, err := o.DeleteProperty("name.middle")
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(o.Binary(), o3.Binary()))
+	o3, err = o.DeleteProperty("age.x")
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(o.Binary(), o3.Binary()))
+}
+
+func TestMembersNew(t *testing.T) {
+	o := ParseJSON(`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}`)
+	assert.Nil(t, o.Members())
+	o, err := o.SetProperty("a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":"b"}}`, o.JSON())
+	o, err = ParseJSON(`{"type":"Feature","properties":null,"geometry":{"type":"Point","coordinates":[1,2]}}`).
+		SetProperty("a.b", true)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"properties":{"a":{"b":true}}}`, string(o.Members()))
+	o, err = o.DeleteProperty("a")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"properties":{}}`, string(o.Members()))
+}
+
+func TestMembersArrays(t *testing.T) {
+	o := ParseJSON(`{"type":"Feature","properties":{"tags":["a","b",{"c":1}]},"geometry":{"type":"Point","coordinates":[1,2]}}`)
+	assert.Equal(t, "a", o.Property("tags.0").String())
+	o2, err := o.SetProperty("tags.0", "z")
+	assert.NoError(t, err)
+	assert.Equal(t, "z", o2.Property("tags.0").String())
+	o2, err = o2.SetProperty("tags.2.d", 2)
+	assert.NoError(t, err)
+	o2, err = o2.SetProperty("tags.3", "e")
+	assert.NoError(t, err)
+	o2, err = o2.DeleteProperty("tags.1")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"properties":{"tags":["z",{"c":1,"d":2},"e"]}}`, string(o2.Members()))
+	assert.Equal(t, int64(2), o2.Property("tags.1.d").Int())
+	o2, err = o2.DeleteProperty("tags.1.c")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"properties":{"tags":["z",{"d":2},"e"]}}`, string(o2.Members()))
+
+	// a number is a key of an object
+	o2, err = o.SetProperty("names.0", "x")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", o2.Property("names.0").String())
+
+	// deleting a missing element returns the original
+	for _, path := range []string{"tags.3", "tags.x", "tags.2.x", "tags.0.x"} {
+		o2, err = o.DeleteProperty(path)
+		assert.NoError(t, err, path)
+		assert.True(t, bytes.Equal(o.Binary(), o2.Binary()), path)
+	}
+	for _, path := range []string{"tags.4", "tags.x", "tags.-1", "tags.0.x"} {
+		_, err = o.SetProperty(path, 1)
+		assert.Equal(t, errInvalidPath, err, path)
+	}
+}
+
+func TestMembersErrors(t *testing.T) {
+	f := ParseJSON(`{"type":"Feature","properties":{"a":1},"geometry":{"type":"Point","coordinates":[1,2]}}`)
+	for _, path := range []string{"", "a.", ".a", "a..b", "a\\", "a*", "a?", "#", "a.#", "a|b",
+		"@this", "a.@this", "[a]", "{a}", "a.[b]"} {
+		_, err := f.SetProperty(path, 1)
+		assert.Error(t, err, path)
+		_, err = f.DeleteProperty(path)
+		assert.Error(t, err, path)
+	}
+	_, err := f.SetProperty("a.b", 1)
+	assert.Equal(t, errInvalidPath, err)
+	_, err = f.SetProperty("b", func() {})
+	assert.Error(t, err)
+	for _, o := range []Object{{}, MakeString("hello"), Make2DPoint(1, 2), ParseJSON(testPolyHoles)} {
+		_, err := o.SetID(1)
+		assert.Equal(t, errNotFeature, err)
+		assert.False(t, o.ID().Exists())
+	}
+}
@@ -0,0 +1,716 @@
+package geobin
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+var errInvalidMVT = errors.New("invalid mvt")
+
+// MVT geometry types
+const (
+	mvtPoint      = 1
+	mvtLineString = 2
+	mvtPolygon    = 3
+)
+
+// MVT geometry commands
+const (
+	mvtMoveTo    = 1
+	mvtLineTo    = 2
+	mvtClosePath = 7
+)
+
+// protobuf wire types
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+// maxLatitude is the latitude where the Web Mercator projection is square.
+const maxLatitude = 85.05112877980659
+
+// MVTOptions are the options for EncodeMVTLayer.
+type MVTOptions struct {
+	// Extent is the width and height of the tile in tile coordinates. Zero
+	// uses 4096.
+	Extent int
+	// Buffer is the distance, in tile coordinates, outside of the tile that
+	// is kept when clipping.
+	Buffer int
+}
+
+// MVTLayer is a layer of a decoded vector tile.
+type MVTLayer struct {
+	Name     string
+	Extent   int
+	Features []Object
+}
+
+// EncodeMVTLayer returns a Mapbox Vector Tile, version 2, with a single
+// layer that has the features. The positions are projected from longitude
+// and latitude to the z/x/y tile with Web Mercator, and the geometries are
+// clipped to the tile plus the buffer. The "id" and "properties" of each
+// Feature are written as the feature id and tags. Property values that are
+// objects or arrays are written as JSON strings. FeatureCollections and
+// GeometryCollections are split into one feature per geometry. Features that
+// are outside of the tile are skipped. A nil opts uses an extent of 4096 and
+// a buffer of 64. The tiles of multiple layers may be concatenated.
+func EncodeMVTLayer(name string, z, x, y int, features []Object, opts *MVTOptions) []byte {
+	if opts == nil {
+		opts = &MVTOptions{Buffer: 64}
+	}
+	l := mvtEncoder{x: x, y: y, extent: opts.Extent,
+		keyIdx: map[string]int{}, valueIdx: map[string]int{}}
+	if l.extent <= 0 {
+		l.extent = 4096
+	}
+	l.n = math.Exp2(float64(z))
+	b := float64(opts.Buffer) / float64(l.extent)
+	l.bbox = BBox{
+		Min: Position{X: tileLon(float64(x)-b, l.n), Y: tileLat(float64(y+1)+b, l.n)},
+		Max: Position{X: tileLon(float64(x+1)+b, l.n), Y: tileLat(float64(y)-b, l.n)},
+	}
+	for _, o := range features {
+		if o.IsGeometry() && o.valid() {
+			l.addObject(o)
+		}
+	}
+	var layer []byte
+	layer = appendPBVarint(layer, 15, 2) // version
+	layer = appendPBBytes(layer, 1, []byte(name))
+	layer = append(layer, l.features...)
+	for _, key := range l.keys {
+		layer = appendPBBytes(layer, 3, []byte(key))
+	}
+	for _, value := range l.values {
+		layer = appendPBBytes(layer, 4, value)
+	}
+	layer = appendPBVarint(layer, 5, uint64(l.extent))
+	return appendPBBytes(nil, 3, layer)
+}
+
+// tileLon returns the longitude of the tile x at n tiles per axis.
+func tileLon(x, n float64) float64 {
+	return x/n*360 - 180
+}
+
+// tileLat returns the latitude of the tile y at n tiles per axis.
+func tileLat(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
+
+type mvtEncoder struct {
+	x, y     int
+	n        float64 // tiles per axis
+	extent   int
+	bbox     BBox // the tile and buffer in longitude and latitude
+	keys     []string
+	keyIdx   map[string]int
+	values   [][]byte // encoded Value messages
+	valueIdx map[string]int
+	features []byte // encoded Feature fields
+	geom     []uint32
+	cx, cy   int // command cursor
+}
+
+// addObject adds a valid geometry and its children.
+func (l *mvtEncoder) addObject(o Object) {
+	switch o.GeometryType() {
+	case FeatureCollection:
+		o.ForEachChild(func(_ int, child Object) bool {
+			l.addObject(child)
+			return true
+		})
+	case Feature:
+		l.addGeometry(o.FeatureGeometry(), o.Members())
+	default:
+		l.addGeometry(o, nil)
+	}
+}
+
+// addGeometry adds a geometry with the members of its Feature.
+func (l *mvtEncoder) addGeometry(g Object, members []byte) {
+	typ := g.GeometryType()
+	if typ == GeometryCollection {
+		g.ForEachChild(func(_ int, child Object) bool {
+			l.addGeometry(child, members)
+			return true
+		})
+		return
+	}
+	if !g.IntersectsBBox(l.bbox) {
+		return
+	}
+	g = g.ClipToBBox(l.bbox)
+	l.geom = l.geom[:0]
+	l.cx, l.cy = 0, 0
+	var mtyp uint64
+	switch typ {
+	case Point, MultiPoint:
+		mtyp = mvtPoint
+		l.appendPoints(g)
+	case LineString, MultiLineString:
+		mtyp = mvtLineString
+		g.Geometry().ForEachRing(func(r RingReader) bool {
+			l.appendLine(r)
+			return true
+		})
+	case Polygon, MultiPolygon:
+		mtyp = mvtPolygon
+		g.Geometry().ForEachPart(func(part Geometry) bool {
+			var outside bool
+			forEachPolygonRing(part, func(i int, r RingReader) bool {
+				if i == 0 {
+					outside = !l.appendRing(r, true)
+				} else if !outside {
+					l.appendRing(r, false)
+				}
+				return !outside
+			})
+			return true
+		})
+	}
+	if len(l.geom) == 0 {
+		return
+	}
+	var f []byte
+	if id := gjson.GetBytes(members, "id"); id.Type == gjson.Number {
+		if v, err := strconv.ParseUint(id.Raw, 10, 64); err == nil {
+			f = appendPBVarint(f, 1, v)
+		}
+	}
+	if tags := l.tags(members); len(tags) > 0 {
+		f = appendPBPacked(f, 2, tags)
+	}
+	f = appendPBVarint(f, 3, mtyp)
+	f = appendPBPacked(f, 4, l.geom)
+	l.features = appendPBBytes(l.features, 2, f)
+}
+
+// tags returns the interned key and value indexes of the properties.
+func (l *mvtEncoder) tags(members []byte) []uint32 {
+	var tags []uint32
+	gjson.GetBytes(members, "properties").ForEach(func(key, val gjson.Result) bool {
+		var value []byte
+		switch val.Type {
+		case gjson.Null:
+			return true
+		case gjson.String:
+			value = appendPBBytes(value, 1, []byte(val.Str))
+		case gjson.True, gjson.False:
+			var v uint64
+			if val.Bool() {
+				v = 1
+			}
+			value = appendPBVarint(value, 7, v)
+		case gjson.Number:
+			f := val.Num
+			switch {
+			case f != math.Trunc(f) || math.Abs(f) > 1<<53:
+				value = appendPBTag(value, 3, pbFixed64)
+				value = appendUint64(value, math.Float64bits(f))
+			case f < 0:
+				value = appendPBVarint(value, 6, zigzag(int64(f)))
+			default:
+				value = appendPBVarint(value, 5, uint64(f))
+			}
+		default:
+			value = appendPBBytes(value, 1, []byte(val.Raw))
+		}
+		k, ok := l.keyIdx[key.String()]
+		if !ok {
+			k = len(l.keys)
+			l.keyIdx[key.String()] = k
+			l.keys = append(l.keys, key.String())
+		}
+		v, ok := l.valueIdx[string(value)]
+		if !ok {
+			v = len(l.values)
+			l.valueIdx[string(value)] = v
+			l.values = append(l.values, value)
+		}
+		tags = append(tags, uint32(k), uint32(v))
+		return true
+	})
+	return tags
+}
+
+// project returns the tile coordinates of a position.
+func (l *mvtEncoder) project(p Position) [2]int {
+	lat := math.Max(-maxLatitude, math.Min(maxLatitude, p.Y)) * math.Pi / 180
+	s := math.Sin(lat)
+	x := (p.X + 180) / 360 * l.n
+	y := (0.5 - math.Log((1+s)/(1-s))/(4*math.Pi)) * l.n
+	return [2]int{
+		int(math.Round((x - float64(l.x)) * float64(l.extent))),
+		int(math.Round((y - float64(l.y)) * float64(l.extent))),
+	}
+}
+
+// projectRing returns the tile coordinates of a ring, without consecutive
+// duplicates.
+func (l *mvtEncoder) projectRing(r RingReader) [][2]int {
+	pts := make([][2]int, 0, r.Len())
+	for i := 0; i < r.Len(); i++ {
+		p := l.project(r.At(i))
+		if len(pts) == 0 || pts[len(pts)-1] != p {
+			pts = append(pts, p)
+		}
+	}
+	return pts
+}
+
+// command appends a command and the deltas of its positions.
+func (l *mvtEncoder) command(id int, pts [][2]int) {
+	l.geom = append(l.geom, uint32(id&7|len(pts)<<3))
+	for _, p := range pts {
+		l.geom = append(l.geom,
+			uint32(zigzag(int64(p[0]-l.cx))), uint32(zigzag(int64(p[1]-l.cy))))
+		l.cx, l.cy = p[0], p[1]
+	}
+}
+
+func (l *mvtEncoder) appendPoints(g Object) {
+	var pts [][2]int
+	g.ForEachPosition(func(p Position) bool {
+		pts = append(pts, l.project(p))
+		return true
+	})
+	if len(pts) > 0 {
+		l.command(mvtMoveTo, pts)
+	}
+}
+
+func (l *mvtEncoder) appendLine(r RingReader) {
+	pts := l.projectRing(r)
+	if len(pts) < 2 {
+		return
+	}
+	l.command(mvtMoveTo, pts[:1])
+	l.command(mvtLineTo, pts[1:])
+}
+
+// appendRing appends a ring that is wound clockwise in tile coordinates for
+// an exterior, and counterclockwise for a hole. Returns false if the ring
+// has no area.
+func (l *mvtEncoder) appendRing(r RingReader, exterior bool) bool {
+	pts := l.projectRing(r)
+	if len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	area := mvtArea(pts)
+	if len(pts) < 3 || area == 0 {
+		return false
+	}
+	if (area > 0) != exterior {
+		reverseTilePoints(pts[1:])
+	}
+	l.command(mvtMoveTo, pts[:1])
+	l.command(mvtLineTo, pts[1:])
+	l.geom = append(l.geom, mvtClosePath|1<<3)
+	return true
+}
+
+func reverseTilePoints(pts [][2]int) {
+	for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+		pts[i], pts[j] = pts[j], pts[i]
+	}
+}
+
+// mvtArea returns twice the signed area of a ring, which is positive when
+// the ring is clockwise in tile coordinates.
+func mvtArea(pts [][2]int) int {
+	var area int
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	return area
+}
+
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+func unzigzag(n uint64) int64 {
+	return int64(n>>1) ^ -int64(n&1)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	for i := 0; i < 8; i++ {
+		b = append(b, byte(v>>(i*8)))
+	}
+	return b
+}
+
+func appendPBTag(b []byte, field, wire int) []byte {
+	return appendUvarint(b, uint64(field<<3|wire))
+}
+
+func appendPBVarint(b []byte, field int, v uint64) []byte {
+	return appendUvarint(appendPBTag(b, field, pbVarint), v)
+}
+
+func appendPBBytes(b []byte, field int, data []byte) []byte {
+	b = appendUvarint(appendPBTag(b, field, pbBytes), uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendPBPacked(b []byte, field int, vals []uint32) []byte {
+	var data []byte
+	for _, v := range vals {
+		data = appendUvarint(data, uint64(v))
+	}
+	return appendPBBytes(b, field, data)
+}
+
+// readUvarint reads a varint at i and returns the index after it.
+func readUvarint(data []byte, i int) (uint64, int, error) {
+	var v uint64
+	for shift := 0; shift < 64; shift += 7 {
+		if i >= len(data) {
+			break
+		}
+		c := data[i]
+		i++
+		v |= uint64(c&0x7F) << shift
+		if c < 0x80 {
+			return v, i, nil
+		}
+	}
+	return 0, 0, errInvalidMVT
+}
+
+// readPBField reads the protobuf field at i. The value of a varint or fixed
+// field is returned in v, and the bytes of a length delimited field in b.
+func readPBField(data []byte, i int) (field, wire int, v uint64, b []byte, j int, err error) {
+	key, i, err := readUvarint(data, i)
+	if err != nil {
+		return 0, 0, 0, nil, 0, err
+	}
+	field, wire = int(key>>3), int(key&7)
+	switch wire {
+	case pbVarint:
+		v, i, err = readUvarint(data, i)
+	case pbBytes:
+		v, i, err = readUvarint(data, i)
+		if err == nil {
+			if v > uint64(len(data)-i) {
+				err = errInvalidMVT
+			} else {
+				b = data[i : i+int(v)]
+				i += int(v)
+			}
+		}
+	case pbFixed64, pbFixed32:
+		size := 8
+		if wire == pbFixed32 {
+			size = 4
+		}
+		if len(data)-i < size {
+			err = errInvalidMVT
+		} else {
+			for k := size - 1; k >= 0; k-- {
+				v = v<<8 | uint64(data[i+k])
+			}
+			i += size
+		}
+	default:
+		err = errInvalidMVT
+	}
+	if err != nil {
+		return 0, 0, 0, nil, 0, err
+	}
+	return field, wire, v, b, i, nil
+}
+
+// readPBPacked reads the values of a packed field, or the single value of
+// an unpacked one.
+func readPBPacked(vals []uint32, wire int, v uint64, b []byte) ([]uint32, error) {
+	if wire == pbVarint {
+		return append(vals, uint32(v)), nil
+	}
+	if wire != pbBytes {
+		return nil, errInvalidMVT
+	}
+	for i := 0; i < len(b); {
+		var err error
+		if v, i, err = readUvarint(b, i); err != nil {
+			return nil, err
+		}
+		vals = append(vals, uint32(v))
+	}
+	return vals, nil
+}
+
+// DecodeMVT decodes the layers of a Mapbox Vector Tile at z/x/y. The tile
+// coordinates are converted to longitude and latitude, and each feature
+// becomes a Feature with its "id" and "properties". Features that are not a
+// point, line, or polygon, or that have no positions, are skipped.
+func DecodeMVT(data []byte, z, x, y int) ([]MVTLayer, error) {
+	var layers []MVTLayer
+	for i := 0; i < len(data); {
+		field, wire, _, b, j, err := readPBField(data, i)
+		if err != nil {
+			return nil, err
+		}
+		i = j
+		if field != 3 {
+			continue
+		}
+		if wire != pbBytes {
+			return nil, errInvalidMVT
+		}
+		layer, err := decodeMVTLayer(b, z, x, y)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+type mvtDecoder struct {
+	x, y   int
+	n      float64 // tiles per axis
+	extent int
+	keys   []string
+	values []string // JSON values
+}
+
+func decodeMVTLayer(data []byte, z, x, y int) (MVTLayer, error) {
+	layer := MVTLayer{Extent: 4096}
+	d := mvtDecoder{x: x, y: y, n: math.Exp2(float64(z))}
+	var features [][]byte
+	for i := 0; i < len(data); {
+		field, wire, v, b, j, err := readPBField(data, i)
+		if err != nil {
+			return layer, err
+		}
+		i = j
+		if (field >= 1 && field <= 4 && wire != pbBytes) ||
+			(field == 5 && wire != pbVarint) {
+			return layer, errInvalidMVT
+		}
+		switch field {
+		case 1:
+			layer.Name = string(b)
+		case 2:
+			features = append(features, b)
+		case 3:
+			d.keys = append(d.keys, string(b))
+		case 4:
+			value, err := decodeMVTValue(b)
+			if err != nil {
+				return layer, err
+			}
+			d.values = append(d.values, value)
+		case 5:
+			layer.Extent = int(v)
+		}
+	}
+	if layer.Extent <= 0 {
+		return layer, errInvalidMVT
+	}
+	d.extent = layer.Extent
+	for _, f := range features {
+		o, err := d.feature(f)
+		if err != nil {
+			return layer, err
+		}
+		if o.data != nil {
+			layer.Features = append(layer.Features, o)
+		}
+	}
+	return layer, nil
+}
+
+// decodeMVTValue returns a Value message as JSON.
+func decodeMVTValue(data []byte) (string, error) {
+	var value string
+	for i := 0; i < len(data); {
+		field, _, v, b, j, err := readPBField(data, i)
+		if err != nil {
+			return "", err
+		}
+		i = j
+		switch field {
+		case 1:
+			value = string(appendJSONStringBytes(nil, b))
+		case 2:
+			value = strconv.FormatFloat(float64(math.Float32frombits(uint32(v))), 'f', -1, 32)
+		case 3:
+			value = strconv.FormatFloat(math.Float64frombits(v), 'f', -1, 64)
+		case 4:
+			value = strconv.FormatInt(int64(v), 10)
+		case 5:
+			value = strconv.FormatUint(v, 10)
+		case 6:
+			value = strconv.FormatInt(unzigzag(v), 10)
+		case 7:
+			value = strconv.FormatBool(v != 0)
+		}
+	}
+	if value == "" || value == "NaN" || value == "+Inf" || value == "-Inf" {
+		value = "null"
+	}
+	return value, nil
+}
+
+// feature decodes a Feature message. Returns an empty object if the feature
+// is skipped.
+func (d *mvtDecoder) feature(data []byte) (Object, error) {
+	var id, props gjson.Result
+	var typ uint64
+	var tags, cmds []uint32
+	for i := 0; i < len(data); {
+		field, wire, v, b, j, err := readPBField(data, i)
+		if err != nil {
+			return Object{}, err
+		}
+		i = j
+		switch field {
+		case 1:
+			id = gjson.Parse(strconv.FormatUint(v, 10))
+		case 2:
+			tags, err = readPBPacked(tags, wire, v, b)
+		case 3:
+			typ = v
+		case 4:
+			cmds, err = readPBPacked(cmds, wire, v, b)
+		}
+		if err != nil {
+			return Object{}, err
+		}
+	}
+	if len(tags)%2 != 0 {
+		return Object{}, errInvalidMVT
+	}
+	json := []byte{'{'}
+	for i := 0; i < len(tags); i += 2 {
+		k, v := int(tags[i]), int(tags[i+1])
+		if k >= len(d.keys) || v >= len(d.values) {
+			return Object{}, errInvalidMVT
+		}
+		if i > 0 {
+			json = append(json, ',')
+		}
+		json = appendJSONStringBytes(json, []byte(d.keys[k]))
+		json = append(json, ':')
+		json = append(json, d.values[v]...)
+	}
+	props = gjson.ParseBytes(append(json, '}'))
+	g, err := d.geometry(typ, cmds)
+	if err != nil || g.data == nil {
+		return Object{}, err
+	}
+	return featureObject(gjson.Result{}, g, id, props), nil
+}
+
+// geometry decodes the commands of a feature.
+func (d *mvtDecoder) geometry(typ uint64, cmds []uint32) (Object, error) {
+	var lines [][][2]int
+	var cx, cy int
+	for i := 0; i < len(cmds); {
+		id, count := int(cmds[i]&7), int(cmds[i]>>3)
+		i++
+		switch id {
+		case mvtMoveTo, mvtLineTo:
+			if count > (len(cmds)-i)/2 {
+				return Object{}, errInvalidMVT
+			}
+			for k := 0; k < count; k++ {
+				cx += int(unzigzag(uint64(cmds[i])))
+				cy += int(unzigzag(uint64(cmds[i+1])))
+				i += 2
+				if id == mvtMoveTo && (typ != mvtPoint || len(lines) == 0) {
+					lines = append(lines, nil)
+				} else if len(lines) == 0 {
+					return Object{}, errInvalidMVT
+				}
+				lines[len(lines)-1] = append(lines[len(lines)-1], [2]int{cx, cy})
+			}
+		case mvtClosePath:
+			if len(lines) == 0 || len(lines[len(lines)-1]) == 0 {
+				return Object{}, errInvalidMVT
+			}
+			line := lines[len(lines)-1]
+			lines[len(lines)-1] = append(line, line[0])
+		default:
+			return Object{}, errInvalidMVT
+		}
+	}
+	switch typ {
+	case mvtPoint:
+		if len(lines) == 0 {
+			break
+		}
+		points := d.unproject(lines[0])
+		if len(points) == 1 {
+			return Make2DPoint(points[0].X, points[0].Y), nil
+		}
+		return makeLevel1(MultiPoint, points, 2), nil
+	case mvtLineString:
+		var series [][]Position
+		for _, line := range lines {
+			if len(line) > 1 {
+				series = append(series, d.unproject(line))
+			}
+		}
+		switch len(series) {
+		case 0:
+		case 1:
+			return makeLevel1(LineString, series[0], 2), nil
+		default:
+			return makeLevel2(MultiLineString, series, 2), nil
+		}
+	case mvtPolygon:
+		var polys [][][]Position
+		for _, ring := range lines {
+			area := mvtArea(ring)
+			if len(ring) < 4 || area == 0 {
+				continue
+			}
+			if area > 0 || len(polys) == 0 {
+				polys = append(polys, nil)
+			}
+			// GeoJSON exteriors are counterclockwise
+			reverseTilePoints(ring)
+			polys[len(polys)-1] = append(polys[len(polys)-1], d.unproject(ring))
+		}
+		switch len(polys) {
+		case 0:
+		case 1:
+			return makeLevel2(Polygon, polys[0], 2), nil
+		default:
+			return makeLevel3(MultiPolygon, polys, 2), nil
+		}
+	}
+	return Object{}, nil
+}
+
+// unproject returns the longitude and latitude of tile coordinates.
+func (d *mvtDecoder) unproject(pts [][2]int) []Position {
+	positions := make([]Position, len(pts))
+	for i, p := range pts {
+		positions[i] = Position{
+			X: tileLon(float64(d.x)+float64(p[0])/float64(d.extent), d.n),
+			Y: tileLat(float64(d.y)+float64(p[1])/float64(d.extent), d.n),
+		}
+	}
+	return positions
+}
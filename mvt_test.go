@@ -0,0 +1,164 @@
+package geobin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeMVTLayerBytes(t *testing.T) {
+	tile := EncodeMVTLayer("a", 0, 0, 0, []Object{Make2DPoint(0, 0)}, nil)
+	feature := []byte{
+		0x18, 1, // type = point
+		0x22, 5, 9, 0x80, 0x20, 0x80, 0x20, // geometry = MoveTo(2048,2048)
+	}
+	layer := []byte{0x78, 2, 0x0A, 1, 'a', 0x12, byte(len(feature))}
+	layer = append(layer, feature...)
+	layer = append(layer, 0x28, 0x80, 0x20) // extent = 4096
+	expect := append([]byte{0x1A, byte(len(layer))}, layer...)
+	assert.Equal(t, expect, tile)
+}
+
+// assertMVTPositions checks that the positions are within the precision of
+// a z0 tile.
+func assertMVTPositions(t *testing.T, expect, actual Object) {
+	t.Helper()
+	ep, ap := collectPositions(expect), collectPositions(actual)
+	if !assert.Equal(t, len(ep), len(ap)) {
+		return
+	}
+	for i := range ep {
+		assert.InDelta(t, ep[i].X, ap[i].X, 0.05)
+		assert.InDelta(t, ep[i].Y, ap[i].Y, 0.05)
+	}
+}
+
+func TestMVTRoundTrip(t *testing.T) {
+	features := []Object{
+		ParseJSON(`{"type":"Feature","id":7,"geometry":{"type":"Point","coordinates":[10,20]},
+			"properties":{"name":"a","n":3,"neg":-4,"f":1.5,"ok":true,"none":null,"obj":{"x":[1,2]}}}`),
+		ParseJSON(`{"type":"Feature","id":"str","geometry":{"type":"LineString","coordinates":[[0,0],[10,10],[20,0]]},
+			"properties":{"name":"a","n":4}}`),
+		ParseJSON(`{"type":"Polygon","coordinates":[[[0,0],[40,0],[40,40],[0,40],[0,0]],[[10,10],[10,20],[20,20],[20,10],[10,10]]]}`),
+		ParseJSON(`{"type":"MultiPoint","coordinates":[[1,1],[2,2]]}`),
+		ParseJSON(`{"type":"MultiPolygon","coordinates":[[[[0,0],[10,0],[10,10],[0,0]]],[[[20,20],[30,20],[30,30],[20,20]]]]}`),
+	}
+	tile := EncodeMVTLayer("roads", 0, 0, 0, features, nil)
+	layers, err := DecodeMVT(tile, 0, 0, 0)
+	assert.NoError(t, err)
+	if !assert.Len(t, layers, 1) {
+		return
+	}
+	assert.Equal(t, "roads", layers[0].Name)
+	assert.Equal(t, 4096, layers[0].Extent)
+	out := layers[0].Features
+	if !assert.Len(t, out, len(features)) {
+		return
+	}
+	assert.Equal(t, `{"id":7,"properties":{"name":"a","n":3,"neg":-4,"f":1.5,"ok":true,"obj":"{\"x\":[1,2]}"}}`,
+		string(out[0].Members()))
+	assert.Equal(t, `{"properties":{"name":"a","n":4}}`, string(out[1].Members()))
+	assert.Equal(t, `{"properties":{}}`, string(out[2].Members()))
+	for i, typ := range []GeometryType{Point, LineString, Polygon, MultiPoint, MultiPolygon} {
+		assert.Equal(t, Feature, out[i].GeometryType())
+		g := out[i].FeatureGeometry()
+		assert.Equal(t, typ, g.GeometryType())
+		expect := features[i]
+		if expect.GeometryType() == Feature {
+			expect = expect.FeatureGeometry()
+		}
+		assertMVTPositions(t, expect, g)
+	}
+	// the keys and values are interned
+	assert.Equal(t, 1, countBytes(tile, "name"))
+	assert.Equal(t, 1, countBytes(tile, "\x0a\x01a"))
+}
+
+func countBytes(b []byte, s string) int {
+	var n int
+	for i := 0; i+len(s) <= len(b); i++ {
+		if string(b[i:i+len(s)]) == s {
+			n++
+		}
+	}
+	return n
+}
+
+func TestEncodeMVTLayerClip(t *testing.T) {
+	// the north west tile at z1
+	features := []Object{
+		ParseJSON(`{"type":"LineString","coordinates":[[-90,10],[90,10]]}`),
+		ParseJSON(`{"type":"Point","coordinates":[90,-45]}`),
+		ParseJSON(`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[-10,10]},
+			{"type":"Point","coordinates":[30,-30]}]}`),
+	}
+	tile := EncodeMVTLayer("a", 1, 0, 0, features, &MVTOptions{Extent: 256, Buffer: 16})
+	layers, err := DecodeMVT(tile, 1, 0, 0)
+	assert.NoError(t, err)
+	out := layers[0].Features
+	if !assert.Len(t, out, 2) {
+		return
+	}
+	line := collectPositions(out[0])
+	assert.InDelta(t, -90, line[0].X, 1)
+	assert.InDelta(t, tileLon(1+16.0/256, 2), line[1].X, 1e-9)
+	assert.InDelta(t, 10, line[1].Y, 1)
+	assert.Equal(t, Point, out[1].FeatureGeometry().GeometryType())
+
+	// no buffer
+	tile = EncodeMVTLayer("a", 1, 0, 0, features[:1], &MVTOptions{Extent: 256})
+	layers, _ = DecodeMVT(tile, 1, 0, 0)
+	line = collectPositions(layers[0].Features[0])
+	assert.InDelta(t, 0, line[1].X, 1e-9)
+
+	// nothing in the tile
+	tile = EncodeMVTLayer("a", 1, 1, 1, features[:1], nil)
+	layers, _ = DecodeMVT(tile, 1, 1, 1)
+	assert.Len(t, layers[0].Features, 0)
+}
+
+func TestEncodeMVTLayerWinding(t *testing.T) {
+	ccw := `{"type":"Polygon","coordinates":[[[0,0],[40,0],[40,40],[0,40],[0,0]],[[10,10],[10,20],[20,20],[20,10],[10,10]]]}`
+	cw := `{"type":"Polygon","coordinates":[[[0,0],[0,40],[40,40],[40,0],[0,0]],[[10,10],[20,10],[20,20],[10,20],[10,10]]]}`
+	for _, json := range []string{ccw, cw} {
+		tile := EncodeMVTLayer("a", 0, 0, 0, []Object{ParseJSON(json)}, nil)
+		layers, err := DecodeMVT(tile, 0, 0, 0)
+		assert.NoError(t, err)
+		g := layers[0].Features[0].FeatureGeometry()
+		assert.Equal(t, Polygon, g.GeometryType())
+		var areas []float64
+		g.Geometry().ForEachRing(func(r RingReader) bool {
+			var area float64
+			for i := 1; i < r.Len(); i++ {
+				a, b := r.At(i-1), r.At(i)
+				area += a.X*b.Y - b.X*a.Y
+			}
+			areas = append(areas, area)
+			return true
+		})
+		// exteriors are counterclockwise, and holes clockwise
+		if assert.Len(t, areas, 2) {
+			assert.Greater(t, areas[0], 0.0)
+			assert.Less(t, areas[1], 0.0)
+		}
+	}
+}
+
+func TestDecodeMVTInvalid(t *testing.T) {
+	tile := EncodeMVTLayer("a", 0, 0, 0, []Object{
+		ParseJSON(`{"type":"Feature","id":1,"geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":1}}`),
+	}, nil)
+	for i := 1; i < len(tile); i++ {
+		_, err := DecodeMVT(tile[:i], 0, 0, 0)
+		assert.Equal(t, errInvalidMVT, err, i)
+	}
+	// bad command
+	feature := []byte{0x18, 1, 0x22, 3, 9, 2, 2, 0x22, 1, 0x0B}
+	layer := append([]byte{0x78, 2, 0x0A, 1, 'a', 0x12, byte(len(feature))}, feature...)
+	_, err := DecodeMVT(append([]byte{0x1A, byte(len(layer))}, layer...), 0, 0, 0)
+	assert.Equal(t, errInvalidMVT, err)
+	// empty
+	layers, err := DecodeMVT(nil, 0, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, layers, 0)
+}
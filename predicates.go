@@ -0,0 +1,911 @@
+package geobin
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/tidwall/tile38/geojson"
+	"github.com/tidwall/tile38/geojson/geo"
+)
+
+// The native predicates operate directly on the packed coordinates and
+// produce the same results as the tile38 geojson objects that are returned
+// by bridge(), including its quirks. Collections are the exception and are
+// still bridged.
+
+// view is a zero-copy look at a valid geometry object. It's shaped like the
+// geojson object that the bridge would produce.
+type view struct {
+	obj     Object
+	typ     GeometryType
+	dims    int
+	simple  bool     // simple 2D point, bridges to a geojson.SimplePoint
+	hasBBox bool     // the bbox is exported, or the object is a simple rect
+	bbox    BBox     // only when hasBBox
+	point   Position // only for a Point
+	geom    []byte   // the GEOM data of a complex object
+}
+
+// view returns a view of the object. Returns false if the object is not a
+// valid geometry.
+func (o Object) view() (view, bool) {
+	if !o.IsGeometry() || !o.valid() {
+		return view{}, false
+	}
+	return o.viewValid(), true
+}
+
+// viewValid returns a view of an object that must already be validated.
+func (o Object) viewValid() view {
+	if o.isCompact() {
+		o = o.expand()
+	}
+	v := view{obj: o}
+	tail := o.data[len(o.data)-1]
+	bboxSize := bboxSizeForTail(tail)
+	v.dims = 2
+	if tail>>1&1 == 1 {
+		v.dims = 3
+	}
+	if tail>>3&1 == 0 {
+		// simple
+		v.typ = Point
+		switch bboxSize {
+		case 48, 32:
+			// rect, a bbox around a center point
+			v.hasBBox = true
+			v.bbox = readBBox(o.data, bboxSize)
+			v.point = Position{
+				X: (v.bbox.Max.X + v.bbox.Min.X) / 2,
+				Y: (v.bbox.Max.Y + v.bbox.Min.Y) / 2,
+				Z: (v.bbox.Max.Z + v.bbox.Min.Z) / 2,
+			}
+		case 24:
+			v.point, _ = readPosition(o.data, 3)
+		case 16:
+			v.simple = true
+			v.point, _ = readPosition(o.data, 2)
+		}
+		return v
+	}
+	head := o.data[bboxSize]
+	v.typ = GeometryType(head >> 4)
+	v.geom = o.data[bboxSize+1:]
+	if head&1 == 1 {
+		// has members, skip over
+		sz, data := readUint32(v.geom)
+		v.geom = data[sz:]
+	}
+	if head>>1&1 == 1 {
+		v.hasBBox = true
+		v.bbox = readBBox(o.data, bboxSize)
+	}
+	if v.typ == Point {
+		v.point, _ = readPosition(v.geom, v.dims)
+	}
+	return v
+}
+
+// readBBox reads a [BBOX] component.
+func readBBox(data []byte, bboxSize int) BBox {
+	var b BBox
+	switch bboxSize {
+	case 48:
+		b.Min, data = readPosition(data, 3)
+		b.Max, _ = readPosition(data, 3)
+	case 32:
+		b.Min, data = readPosition(data, 2)
+		b.Max, _ = readPosition(data, 2)
+	case 24:
+		b.Min, _ = readPosition(data, 3)
+		b.Max = b.Min
+	case 16:
+		b.Min, _ = readPosition(data, 2)
+		b.Max = b.Min
+	}
+	return b
+}
+
+func geojsonBBox(bbox BBox) geojson.BBox {
+	return geojson.BBox{
+		Min: geojson.Position{X: bbox.Min.X, Y: bbox.Min.Y, Z: bbox.Min.Z},
+		Max: geojson.Position{X: bbox.Max.X, Y: bbox.Max.Y, Z: bbox.Max.Z},
+	}
+}
+
+func isCollection(typ GeometryType) bool {
+	return typ == GeometryCollection || typ == FeatureCollection
+}
+
+// child returns the geometry of a Feature.
+func (v view) child() view {
+	sz, data := readUint32(v.geom)
+	return Object{data[:sz:sz]}.viewValid()
+}
+
+// children returns the number of children in a collection and the packed
+// [UINT32][OBJECT] data that follows.
+func (v view) children() (int, []byte) {
+	return readUint32(v.geom)
+}
+
+// nextChild reads the next [UINT32][OBJECT] from collection data.
+func nextChild(data []byte) (view, []byte) {
+	sz, data := readUint32(data)
+	return Object{data[:sz:sz]}.viewValid(), data[sz:]
+}
+
+// series is a zero-copy view of packed positions.
+type series struct {
+	data []byte
+	dims int
+	n    int
+}
+
+// readSeries reads a [UINT32][POSITION...] block.
+func readSeries(data []byte, dims int) (series, []byte) {
+	n, data := readUint32(data)
+	sz := n * dims * 8
+	return series{data[:sz:sz], dims, n}, data[sz:]
+}
+
+func (s series) at(i int) Position {
+	p, _ := readPosition(s.data[i*s.dims*8:], s.dims)
+	return p
+}
+
+// seriesList is a zero-copy view of packed series, such as the rings of a
+// polygon or the lines of a multilinestring.
+type seriesList struct {
+	data []byte
+	dims int
+	n    int
+}
+
+// readSeriesList reads a [UINT32][SERIES...] block.
+func readSeriesList(data []byte, dims int) (seriesList, []byte) {
+	n, data := readUint32(data)
+	l := seriesList{data, dims, n}
+	for i := 0; i < n; i++ {
+		_, data = readSeries(data, dims)
+	}
+	l.data = l.data[:len(l.data)-len(data)]
+	return l, data
+}
+
+func (l seriesList) forEach(iter func(s series) bool) bool {
+	data := l.data
+	for i := 0; i < l.n; i++ {
+		var s series
+		s, data = readSeries(data, l.dims)
+		if !iter(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// exteriorHoles splits polygon rings into the exterior and holes.
+func (l seriesList) exteriorHoles() (exterior series, holes seriesList) {
+	if l.n == 0 {
+		return series{dims: l.dims}, seriesList{dims: l.dims}
+	}
+	exterior, data := readSeries(l.data, l.dims)
+	return exterior, seriesList{data, l.dims, l.n - 1}
+}
+
+// forEachPolygon iterates over packed [UINT32][POLYGON...] data.
+func forEachPolygon(data []byte, dims int, iter func(rings seriesList) bool) bool {
+	n, data := readUint32(data)
+	for i := 0; i < n; i++ {
+		var rings seriesList
+		rings, data = readSeriesList(data, dims)
+		if !iter(rings) {
+			return false
+		}
+	}
+	return true
+}
+
+func pointInsideRect(p Position, rect BBox) bool {
+	if p.X < rect.Min.X || p.X > rect.Max.X {
+		return false
+	}
+	if p.Y < rect.Min.Y || p.Y > rect.Max.Y {
+		return false
+	}
+	return true
+}
+
+func rectInsideRect(r, rect BBox) bool {
+	if r.Min.X < rect.Min.X || r.Max.X > rect.Max.X {
+		return false
+	}
+	if r.Min.Y < rect.Min.Y || r.Max.Y > rect.Max.Y {
+		return false
+	}
+	return true
+}
+
+func rectIntersectsRect(r, rect BBox) bool {
+	if r.Min.Y > rect.Max.Y || r.Max.Y < rect.Min.Y {
+		return false
+	}
+	if r.Min.X > rect.Max.X || r.Max.X < rect.Min.X {
+		return false
+	}
+	return true
+}
+
+// unionRect expands the 2D bounds of r to include rect.
+func unionRect(r, rect BBox) BBox {
+	if rect.Min.X < r.Min.X {
+		r.Min.X = rect.Min.X
+	}
+	if rect.Min.Y < r.Min.Y {
+		r.Min.Y = rect.Min.Y
+	}
+	if rect.Max.X > r.Max.X {
+		r.Max.X = rect.Max.X
+	}
+	if rect.Max.Y > r.Max.Y {
+		r.Max.Y = rect.Max.Y
+	}
+	return r
+}
+
+// rect returns the 2D bounding box of the series.
+func (s series) rect() BBox {
+	var r BBox
+	for i := 0; i < s.n; i++ {
+		p := s.at(i)
+		if i == 0 {
+			r.Min, r.Max = p, p
+			continue
+		}
+		if p.X < r.Min.X {
+			r.Min.X = p.X
+		} else if p.X > r.Max.X {
+			r.Max.X = p.X
+		}
+		if p.Y < r.Min.Y {
+			r.Min.Y = p.Y
+		} else if p.Y > r.Max.Y {
+			r.Max.Y = p.Y
+		}
+	}
+	return r
+}
+
+func (s series) insideRect(rect BBox) bool {
+	if s.n == 0 {
+		return false
+	}
+	for i := 0; i < s.n; i++ {
+		if !pointInsideRect(s.at(i), rect) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s series) intersectsRect(rect BBox) bool {
+	if s.n == 0 {
+		return false
+	}
+	var buf [80]byte
+	data := buf[:0]
+	for _, p := range [5]Position{
+		{rect.Min.X, rect.Min.Y, 0}, {rect.Min.X, rect.Max.Y, 0},
+		{rect.Max.X, rect.Max.Y, 0}, {rect.Max.X, rect.Min.Y, 0},
+		{rect.Min.X, rect.Min.Y, 0},
+	} {
+		data = appendFloat64(data, p.X)
+		data = appendFloat64(data, p.Y)
+	}
+	return s.intersects(false, series{data, 2, 5}, seriesList{dims: 2})
+}
+
+func appendFloat64(data []byte, f float64) []byte {
+	v := math.Float64bits(f)
+	return append(data,
+		byte(v), byte(v>>8), byte(v>>16), byte(v>>24),
+		byte(v>>32), byte(v>>40), byte(v>>48), byte(v>>56),
+	)
+}
+
+// raycast is ported from the tile38 poly package.
+func raycast(p, a, b Position) (in, on bool) {
+	// make sure that the point is inside the segment bounds
+	if a.Y < b.Y && (p.Y < a.Y || p.Y > b.Y) {
+		return false, false
+	} else if a.Y > b.Y && (p.Y < b.Y || p.Y > a.Y) {
+		return false, false
+	}
+	// test if point is in on the segment
+	if a.Y == b.Y {
+		if a.X == b.X {
+			return false, p == a
+		}
+		if p.Y == b.Y {
+			// horizontal segment
+			if a.X < b.X {
+				if p.X >= a.X && p.X <= b.X {
+					return false, true
+				}
+			} else {
+				if p.X >= b.X && p.X <= a.X {
+					return false, true
+				}
+			}
+		}
+	}
+	if a.X == b.X && p.X == b.X {
+		// vertical segment
+		if a.Y < b.Y {
+			if p.Y >= a.Y && p.Y <= b.Y {
+				return false, true
+			}
+		} else {
+			if p.Y >= b.Y && p.Y <= a.Y {
+				return false, true
+			}
+		}
+	}
+	if (p.X-a.X)/(b.X-a.X) == (p.Y-a.Y)/(b.Y-a.Y) {
+		return false, true
+	}
+	// do the actual raycast here.
+	for p.Y == a.Y || p.Y == b.Y {
+		p.Y = math.Nextafter(p.Y, math.Inf(1))
+	}
+	if a.Y < b.Y {
+		if p.Y < a.Y || p.Y > b.Y {
+			return false, false
+		}
+	} else {
+		if p.Y < b.Y || p.Y > a.Y {
+			return false, false
+		}
+	}
+	if a.X > b.X {
+		if p.X > a.X {
+			return false, false
+		}
+		if p.X < b.X {
+			return true, false
+		}
+	} else {
+		if p.X > b.X {
+			return false, false
+		}
+		if p.X < a.X {
+			return true, false
+		}
+	}
+	if a.Y < b.Y {
+		if (p.Y-a.Y)/(p.X-a.X) >= (b.Y-a.Y)/(b.X-a.X) {
+			return true, false
+		}
+	} else {
+		if (p.Y-b.Y)/(p.X-b.X) >= (a.Y-b.Y)/(a.X-b.X) {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// insideRing detects if a point is inside a ring. Points on the edge of an
+// exterior ring are inside, while points on the edge of a hole are not.
+func insideRing(p Position, ring series, exterior bool) bool {
+	in := false
+	for i := 0; i < ring.n; i++ {
+		rin, ron := raycast(p, ring.at(i), ring.at((i+1)%ring.n))
+		if ron {
+			return exterior
+		}
+		if rin {
+			in = !in
+		}
+	}
+	return in
+}
+
+// pointInside detects if a point is inside of exterior and not in a hole.
+func pointInside(p Position, exterior series, holes seriesList) bool {
+	if !insideRing(p, exterior, true) {
+		return false
+	}
+	return holes.forEach(func(hole series) bool {
+		return !insideRing(p, hole, false)
+	})
+}
+
+// inside detects if a series is inside of exterior and not in a hole.
+func (s series) inside(exterior series, holes seriesList) bool {
+	for i := 0; i < s.n; i++ {
+		if !pointInside(s.at(i), exterior, holes) {
+			return false
+		}
+	}
+	return holes.forEach(func(hole series) bool {
+		return !hole.inside(s, seriesList{dims: s.dims})
+	})
+}
+
+// intersects detects if a series intersects a polygon. A linestring only
+// intersects when it crosses or is inside of the polygon, while a polygon
+// also intersects when it contains the other polygon.
+func (s series) intersects(isLineString bool, exterior series, holes seriesList) bool {
+	switch s.n {
+	case 0:
+		return false
+	case 1:
+		switch exterior.n {
+		case 0:
+			return false
+		case 1:
+			// tile38 only compares the X coordinates
+			a := s.at(0)
+			return a.X == exterior.at(0).X && !math.IsNaN(a.Y)
+		default:
+			return pointInside(s.at(0), exterior, holes)
+		}
+	default:
+		switch exterior.n {
+		case 0:
+			return false
+		case 1:
+			return pointInside(exterior.at(0), s, holes)
+		}
+	}
+	if !rectIntersectsRect(s.rect(), exterior.rect()) {
+		return false
+	}
+	for i := 0; i < s.n; i++ {
+		a, b := s.at(i), s.at((i+1)%s.n)
+		for j := 0; j < exterior.n; j++ {
+			if segmentsIntersect(a, b, exterior.at(j), exterior.at((j+1)%exterior.n)) {
+				return true
+			}
+		}
+	}
+	none := seriesList{dims: s.dims}
+	if !holes.forEach(func(hole series) bool {
+		return !s.inside(hole, none)
+	}) {
+		return false
+	}
+	if s.inside(exterior, none) {
+		return true
+	}
+	if !isLineString {
+		if exterior.inside(s, none) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentsIntersect detects if the segments ab and cd intersect. Ported from
+// the tile38 poly package.
+func segmentsIntersect(a, b, c, d Position) bool {
+	// do the bounding boxes intersect?
+	if a.Y > b.Y {
+		if c.Y > d.Y {
+			if b.Y > c.Y || a.Y < d.Y {
+				return false
+			}
+		} else {
+			if b.Y > d.Y || a.Y < c.Y {
+				return false
+			}
+		}
+	} else {
+		if c.Y > d.Y {
+			if a.Y > c.Y || b.Y < d.Y {
+				return false
+			}
+		} else {
+			if a.Y > d.Y || b.Y < c.Y {
+				return false
+			}
+		}
+	}
+	if a.X > b.X {
+		if c.X > d.X {
+			if b.X > c.X || a.X < d.X {
+				return false
+			}
+		} else {
+			if b.X > d.X || a.X < c.X {
+				return false
+			}
+		}
+	} else {
+		if c.X > d.X {
+			if a.X > c.X || b.X < d.X {
+				return false
+			}
+		} else {
+			if a.X > d.X || b.X < c.X {
+				return false
+			}
+		}
+	}
+	// the following code is from http://ideone.com/PnPJgb
+	cmpx, cmpy := c.X-a.X, c.Y-a.Y
+	rx, ry := b.X-a.X, b.Y-a.Y
+	cmpxr := cmpx*ry - cmpy*rx
+	if cmpxr == 0 {
+		// Lines are collinear, and so intersect if they have any overlap
+		return ((c.X-a.X <= 0) != (c.X-b.X <= 0)) ||
+			((c.Y-a.Y <= 0) != (c.Y-b.Y <= 0))
+	}
+	sx, sy := d.X-c.X, d.Y-c.Y
+	cmpxs := cmpx*sy - cmpy*sx
+	rxs := rx*sy - ry*sx
+	if rxs == 0 {
+		return false // Lines are parallel.
+	}
+	rxsr := 1 / rxs
+	t := cmpxs * rxsr
+	u := cmpxr * rxsr
+	return (t >= 0) && (t <= 1) && (u >= 0) && (u <= 1)
+}
+
+func (v view) withinBBox(bbox BBox) bool {
+	if isCollection(v.typ) {
+		return v.obj.bridgeValid().WithinBBox(geojsonBBox(bbox))
+	}
+	if v.hasBBox {
+		return rectInsideRect(v.bbox, bbox)
+	}
+	switch v.typ {
+	case Point:
+		return pointInsideRect(v.point, bbox)
+	case MultiPoint, LineString:
+		s, _ := readSeries(v.geom, v.dims)
+		return s.insideRect(bbox)
+	case MultiLineString:
+		l, _ := readSeriesList(v.geom, v.dims)
+		return l.n > 0 && l.forEach(func(s series) bool {
+			return s.insideRect(bbox)
+		})
+	case Polygon:
+		l, _ := readSeriesList(v.geom, v.dims)
+		exterior, _ := l.exteriorHoles()
+		return l.n > 0 && exterior.insideRect(bbox)
+	case MultiPolygon:
+		n, _ := readUint32(v.geom)
+		return n > 0 && forEachPolygon(v.geom, v.dims, func(rings seriesList) bool {
+			exterior, _ := rings.exteriorHoles()
+			return rings.n > 0 && exterior.insideRect(bbox)
+		})
+	case Feature:
+		return v.child().withinBBox(bbox)
+	}
+	return false
+}
+
+func (v view) intersectsBBox(bbox BBox) bool {
+	if isCollection(v.typ) {
+		return v.obj.bridgeValid().IntersectsBBox(geojsonBBox(bbox))
+	}
+	if v.hasBBox {
+		return rectIntersectsRect(v.bbox, bbox)
+	}
+	switch v.typ {
+	case Point:
+		return pointInsideRect(v.point, bbox)
+	case MultiPoint:
+		s, _ := readSeries(v.geom, v.dims)
+		for i := 0; i < s.n; i++ {
+			if pointInsideRect(s.at(i), bbox) {
+				return true
+			}
+		}
+		return false
+	case LineString:
+		s, _ := readSeries(v.geom, v.dims)
+		return s.intersectsRect(bbox)
+	case MultiLineString:
+		l, _ := readSeriesList(v.geom, v.dims)
+		return !l.forEach(func(s series) bool {
+			return !s.intersectsRect(bbox)
+		})
+	case Polygon:
+		l, _ := readSeriesList(v.geom, v.dims)
+		exterior, _ := l.exteriorHoles()
+		return l.n > 0 && exterior.intersectsRect(bbox)
+	case MultiPolygon:
+		return !forEachPolygon(v.geom, v.dims, func(rings seriesList) bool {
+			exterior, _ := rings.exteriorHoles()
+			return !(rings.n > 0 && exterior.intersectsRect(bbox))
+		})
+	case Feature:
+		return v.child().intersectsBBox(bbox)
+	}
+	return false
+}
+
+func (v view) within(o view) bool {
+	if isCollection(v.typ) {
+		return v.obj.bridgeValid().Within(o.obj.bridgeValid())
+	}
+	if o.hasBBox {
+		return v.withinBBox(o.bbox)
+	}
+	switch o.typ {
+	case Point:
+		return v.withinBBox(BBox{o.point, o.point})
+	case Polygon:
+		l, _ := readSeriesList(o.geom, o.dims)
+		if l.n == 0 {
+			return false
+		}
+		if v.typ == Feature {
+			return v.child().within(o)
+		}
+		return v.withinPolygon(l)
+	case MultiPolygon:
+		n, _ := readUint32(o.geom)
+		if n == 0 {
+			return false
+		}
+		if v.typ == Feature {
+			return v.child().within(o)
+		}
+		// tile38 requires the object to be within every polygon
+		return forEachPolygon(o.geom, o.dims, func(rings seriesList) bool {
+			return v.withinPolygon(rings)
+		})
+	case Feature:
+		return v.within(o.child())
+	case GeometryCollection, FeatureCollection:
+		n, data := o.children()
+		if n == 0 {
+			return false
+		}
+		for i := 0; i < n; i++ {
+			var c view
+			c, data = nextChild(data)
+			if !v.within(c) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// withinPolygon detects if the object is within the polygon rings. The object
+// cannot be a Feature or collection.
+func (v view) withinPolygon(rings seriesList) bool {
+	exterior, holes := rings.exteriorHoles()
+	switch v.typ {
+	case Point:
+		return pointInside(v.point, exterior, holes)
+	case MultiPoint:
+		s, _ := readSeries(v.geom, v.dims)
+		for i := 0; i < s.n; i++ {
+			if !pointInside(s.at(i), exterior, holes) {
+				return false
+			}
+		}
+		return s.n > 0
+	case LineString:
+		s, _ := readSeries(v.geom, v.dims)
+		return s.inside(exterior, holes)
+	case MultiLineString:
+		l, _ := readSeriesList(v.geom, v.dims)
+		return l.n > 0 && l.forEach(func(s series) bool {
+			return s.inside(exterior, holes)
+		})
+	case Polygon:
+		l, _ := readSeriesList(v.geom, v.dims)
+		ext, _ := l.exteriorHoles()
+		return l.n > 0 && ext.inside(exterior, holes)
+	case MultiPolygon:
+		n, _ := readUint32(v.geom)
+		return n > 0 && forEachPolygon(v.geom, v.dims, func(prings seriesList) bool {
+			ext, _ := prings.exteriorHoles()
+			return prings.n == 0 || ext.inside(exterior, holes)
+		})
+	}
+	return false
+}
+
+func (v view) intersects(o view) bool {
+	if isCollection(v.typ) {
+		return v.obj.bridgeValid().Intersects(o.obj.bridgeValid())
+	}
+	if o.hasBBox {
+		return v.intersectsBBox(o.bbox)
+	}
+	switch o.typ {
+	case Point:
+		return v.intersectsBBox(BBox{o.point, o.point})
+	case Polygon:
+		l, _ := readSeriesList(o.geom, o.dims)
+		if l.n == 0 {
+			return false
+		}
+		if v.typ == Feature {
+			return v.child().intersects(o)
+		}
+		return v.intersectsPolygon(l)
+	case MultiPolygon:
+		n, _ := readUint32(o.geom)
+		if n == 0 {
+			return false
+		}
+		if v.typ == Feature {
+			return v.child().intersects(o)
+		}
+		return !forEachPolygon(o.geom, o.dims, func(rings seriesList) bool {
+			return !v.intersectsPolygon(rings)
+		})
+	case Feature:
+		return v.intersects(o.child())
+	case GeometryCollection, FeatureCollection:
+		n, data := o.children()
+		for i := 0; i < n; i++ {
+			var c view
+			c, data = nextChild(data)
+			if v.intersects(c) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// intersectsPolygon detects if the object intersects the polygon rings. The
+// object cannot be a Feature or collection.
+func (v view) intersectsPolygon(rings seriesList) bool {
+	exterior, holes := rings.exteriorHoles()
+	switch v.typ {
+	case Point:
+		return pointInside(v.point, exterior, holes)
+	case MultiPoint:
+		// tile38 reports that any non-empty multipoint intersects
+		s, _ := readSeries(v.geom, v.dims)
+		return s.n > 0
+	case LineString:
+		s, _ := readSeries(v.geom, v.dims)
+		return s.intersects(true, exterior, holes)
+	case MultiLineString:
+		l, _ := readSeriesList(v.geom, v.dims)
+		return !l.forEach(func(s series) bool {
+			return !s.intersects(false, exterior, holes)
+		})
+	case Polygon:
+		l, _ := readSeriesList(v.geom, v.dims)
+		ext, _ := l.exteriorHoles()
+		return l.n > 0 && ext.intersects(false, exterior, holes)
+	case MultiPolygon:
+		return !forEachPolygon(v.geom, v.dims, func(prings seriesList) bool {
+			ext, _ := prings.exteriorHoles()
+			return !(prings.n > 0 && ext.intersects(false, exterior, holes))
+		})
+	}
+	return false
+}
+
+// calculatedBBox returns the bbox that tile38 uses for nearby searches, and
+// false if the object has no positions.
+func (v view) calculatedBBox() (BBox, bool) {
+	if v.hasBBox {
+		return v.bbox, true
+	}
+	var bbox BBox
+	var count int
+	add := func(s series) bool {
+		for i := 0; i < s.n; i++ {
+			p := s.at(i)
+			if count == 0 {
+				bbox.Min, bbox.Max = p, p
+			} else {
+				bbox = unionRect(bbox, BBox{p, p})
+			}
+			count++
+		}
+		return true
+	}
+	switch v.typ {
+	case Point:
+		return BBox{v.point, v.point}, true
+	case MultiPoint, LineString:
+		s, _ := readSeries(v.geom, v.dims)
+		add(s)
+	case MultiLineString:
+		l, _ := readSeriesList(v.geom, v.dims)
+		l.forEach(add)
+	case Polygon:
+		// only the exterior ring is used, but any ring counts as positions
+		l, _ := readSeriesList(v.geom, v.dims)
+		exterior, _ := l.exteriorHoles()
+		add(exterior)
+		hasPositions := !l.forEach(func(s series) bool { return s.n == 0 })
+		return bbox, hasPositions
+	case MultiPolygon:
+		forEachPolygon(v.geom, v.dims, func(rings seriesList) bool {
+			return rings.forEach(add)
+		})
+	case Feature:
+		return v.child().calculatedBBox()
+	case GeometryCollection, FeatureCollection:
+		var hasPositions bool
+		n, data := v.children()
+		for i := 0; i < n; i++ {
+			var c view
+			c, data = nextChild(data)
+			cbbox, ok := c.calculatedBBox()
+			if i == 0 {
+				bbox = cbbox
+			} else {
+				bbox = unionRect(bbox, cbbox)
+			}
+			hasPositions = hasPositions || ok
+		}
+		return bbox, hasPositions
+	}
+	return bbox, count > 0
+}
+
+func (v view) nearby(center Position, meters float64) bool {
+	if isCollection(v.typ) {
+		return v.obj.bridgeValid().Nearby(
+			geojson.Position{X: center.X, Y: center.Y, Z: center.Z}, meters,
+		)
+	}
+	if v.typ == Point {
+		// points, including simple rects, use the point coordinates
+		if v.simple {
+			return geo.DistanceTo(center.Y, center.X, v.point.Y, v.point.X) <= meters
+		}
+		return geo.DistanceTo(v.point.Y, v.point.X, center.Y, center.X) <= meters
+	}
+	bbox, ok := v.calculatedBBox()
+	if !ok {
+		return false
+	}
+	if bbox.Min.X == bbox.Max.X && bbox.Min.Y == bbox.Max.Y {
+		// just a point, return is point is inside of the circle
+		return geo.DistanceTo(center.Y, center.X, bbox.Min.Y, bbox.Min.X) <= meters
+	}
+	return v.intersects(circlePolygon(center, meters, 12).viewValid())
+}
+
+// circlePolygon returns a polygon around the radius. It's the same polygon
+// as the tile38 geojson.CirclePolygon.
+func circlePolygon(center Position, meters float64, steps int) Object {
+	ring := make([]Position, 0, steps+1)
+	step := 360.0 / float64(steps)
+	for deg := 360.0; deg > 0; deg -= step {
+		lat, lon := geo.DestinationPoint(center.Y, center.X, meters, deg)
+		ring = append(ring, Position{X: lon, Y: lat})
+	}
+	ring = append(ring, ring[0])
+	min, max := baseMin, baseMax
+	for _, p := range ring {
+		min[0], min[1] = math.Min(min[0], p.X), math.Min(min[1], p.Y)
+		max[0], max[1] = math.Max(max[0], p.X), math.Max(max[1], p.Y)
+	}
+	raw := make([]byte, 0, 32+1+8+len(ring)*16+1)
+	for _, f := range []float64{min[0], min[1], max[0], max[1]} {
+		raw = appendFloat64(raw, f)
+	}
+	raw = append(raw, byte(Polygon)<<4)
+	raw = append(raw, 1, 0, 0, 0)
+	raw = append(raw, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(raw[len(raw)-4:], uint32(len(ring)))
+	for _, p := range ring {
+		raw = appendFloat64(raw, p.X)
+		raw = appendFloat64(raw, p.Y)
+	}
+	raw = append(raw, 13)
+	return Object{raw}
+}
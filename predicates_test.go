@@ -0,0 +1,165 @@
+package geobin
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tidwall/tile38/geojson"
+)
+
+func randTestPosition(rnd *rand.Rand, dims int) string {
+	// a small grid makes shared edges and vertices likely
+	if dims == 3 {
+		return fmt.Sprintf("[%d,%d,%d]", rnd.Intn(12), rnd.Intn(12), rnd.Intn(3))
+	}
+	return fmt.Sprintf("[%d,%d]", rnd.Intn(12), rnd.Intn(12))
+}
+
+func randTestSeries(rnd *rand.Rand, dims int, ring bool) string {
+	n := rnd.Intn(5)
+	if ring {
+		n = 2 + rnd.Intn(5)
+	}
+	var parts []string
+	for i := 0; i < n; i++ {
+		parts = append(parts, randTestPosition(rnd, dims))
+	}
+	if ring {
+		parts = append(parts, parts[0])
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+func randTestPolygon(rnd *rand.Rand, dims int) string {
+	var rings []string
+	for i := 0; i < 1+rnd.Intn(3); i++ {
+		rings = append(rings, randTestSeries(rnd, dims, true))
+	}
+	return "[" + strings.Join(rings, ",") + "]"
+}
+
+func randTestGeometryJSON(rnd *rand.Rand, depth int) string {
+	dims := 2 + rnd.Intn(2)
+	var bbox string
+	if rnd.Intn(5) == 0 {
+		if dims == 3 {
+			bbox = fmt.Sprintf(`,"bbox":[%d,%d,0,%d,%d,3]`,
+				rnd.Intn(6), rnd.Intn(6), 6+rnd.Intn(6), 6+rnd.Intn(6))
+		} else {
+			bbox = fmt.Sprintf(`,"bbox":[%d,%d,%d,%d]`,
+				rnd.Intn(6), rnd.Intn(6), 6+rnd.Intn(6), 6+rnd.Intn(6))
+		}
+	}
+	n := 7
+	if depth > 0 {
+		n = 10
+	}
+	var parts []string
+	switch rnd.Intn(n) {
+	case 0:
+		return `{"type":"Point","coordinates":` + randTestPosition(rnd, dims) + bbox + `}`
+	case 1:
+		return `{"type":"MultiPoint","coordinates":` + randTestSeries(rnd, dims, false) + bbox + `}`
+	case 2:
+		return `{"type":"LineString","coordinates":` + randTestSeries(rnd, dims, false) + bbox + `}`
+	case 3:
+		for i := 0; i < rnd.Intn(3); i++ {
+			parts = append(parts, randTestSeries(rnd, dims, false))
+		}
+		return `{"type":"MultiLineString","coordinates":[` + strings.Join(parts, ",") + "]" + bbox + `}`
+	case 4, 5:
+		return `{"type":"Polygon","coordinates":` + randTestPolygon(rnd, dims) + bbox + `}`
+	case 6:
+		for i := 0; i < rnd.Intn(3); i++ {
+			parts = append(parts, randTestPolygon(rnd, dims))
+		}
+		return `{"type":"MultiPolygon","coordinates":[` + strings.Join(parts, ",") + "]" + bbox + `}`
+	case 7:
+		return `{"type":"Feature","geometry":` + randTestGeometryJSON(rnd, depth-1) + bbox + `}`
+	case 8:
+		for i := 0; i < rnd.Intn(3); i++ {
+			parts = append(parts, randTestGeometryJSON(rnd, depth-1))
+		}
+		return `{"type":"GeometryCollection","geometries":[` + strings.Join(parts, ",") + "]" + bbox + `}`
+	default:
+		for i := 0; i < rnd.Intn(3); i++ {
+			parts = append(parts, `{"type":"Feature","geometry":`+randTestGeometryJSON(rnd, depth-1)+`}`)
+		}
+		return `{"type":"FeatureCollection","features":[` + strings.Join(parts, ",") + "]" + bbox + `}`
+	}
+}
+
+func randTestObject(rnd *rand.Rand) Object {
+	switch rnd.Intn(8) {
+	case 0:
+		return Make2DPoint(float64(rnd.Intn(12)), float64(rnd.Intn(12)))
+	case 1:
+		x, y := float64(rnd.Intn(8)), float64(rnd.Intn(8))
+		return Make2DRect(x, y, x+float64(rnd.Intn(5)), y+float64(rnd.Intn(5)))
+	case 2:
+		x, y := float64(rnd.Intn(8)), float64(rnd.Intn(8))
+		return Make3DRect(x, y, 0, x+float64(rnd.Intn(5)), y+float64(rnd.Intn(5)), 2)
+	}
+	return ParseJSON(randTestGeometryJSON(rnd, 2))
+}
+
+func TestNativePredicates(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 20000; i++ {
+		a, b := randTestObject(rnd), randTestObject(rnd)
+		ga, gb := a.bridge(), b.bridge()
+		x, y := float64(rnd.Intn(8)), float64(rnd.Intn(8))
+		bbox := BBox{Position{x, y, 0}, Position{x + float64(rnd.Intn(5)), y + float64(rnd.Intn(5)), 0}}
+		center := Position{float64(rnd.Intn(12)), float64(rnd.Intn(12)), 0}
+		meters := float64(rnd.Intn(400000))
+		check := func(name string, native, bridged bool) {
+			if native != bridged {
+				t.Fatalf("seed %d: %s mismatch: native %v, bridge %v\na: %s\nb: %s\nbbox: %v\ncenter: %v %v",
+					seed, name, native, bridged, a.JSON(), b.JSON(), bbox, center, meters)
+			}
+		}
+		check("WithinBBox", a.WithinBBox(bbox), ga.WithinBBox(geojsonBBox(bbox)))
+		check("IntersectsBBox", a.IntersectsBBox(bbox), ga.IntersectsBBox(geojsonBBox(bbox)))
+		check("Within", a.Within(b), ga.Within(gb))
+		check("Intersects", a.Intersects(b), ga.Intersects(gb))
+		check("Nearby", a.Nearby(center, meters),
+			ga.Nearby(geojson.Position{X: center.X, Y: center.Y}, meters))
+	}
+}
+
+func TestNativePredicatesStrings(t *testing.T) {
+	s := MakeString("hello")
+	p := Make2DPoint(1, 1)
+	r := Make2DRect(0, 0, 2, 2)
+	if s.Within(r) || s.Intersects(r) || p.Within(s) || p.Intersects(s) ||
+		s.WithinBBox(r.BBox()) || s.Nearby(Position{}, 1000) {
+		t.Fatal("!")
+	}
+	if !p.Within(r) || !p.Intersects(r) || !r.Intersects(p) {
+		t.Fatal("!")
+	}
+}
+
+func BenchmarkPointInPolygon(b *testing.B) {
+	p := Make2DPoint(5, 1)
+	poly := ParseJSON(testPolyHoles)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Within(poly)
+	}
+}
+
+func BenchmarkPointInPolygonBridge(b *testing.B) {
+	p := Make2DPoint(5, 1)
+	poly := ParseJSON(testPolyHoles)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.bridge().Within(poly.bridge())
+	}
+}
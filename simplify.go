@@ -0,0 +1,415 @@
+package geobin
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// SimplifyMethod is the algorithm used by Simplify.
+type SimplifyMethod byte
+
+const (
+	// DouglasPeucker removes the positions that are within the tolerance
+	// distance of the simplified line.
+	DouglasPeucker SimplifyMethod = iota
+	// Visvalingam removes the positions that form a triangle, with their
+	// neighbors, that has an area smaller than the tolerance.
+	Visvalingam
+	// PreserveTopology may be combined with a method, such as
+	// DouglasPeucker|PreserveTopology, to keep the rings of the polygons,
+	// and the lines, of an object from crossing. Lines and rings that would
+	// cross are simplified less.
+	PreserveTopology SimplifyMethod = 1 << 7
+)
+
+// Simplify returns a copy of the object with fewer positions. The tolerance
+// is in the units of the coordinates, or square units for Visvalingam.
+// Lines keep their first and last positions, and rings stay closed with at
+// least four positions. Features and collections simplify their children.
+// The bbox is recalculated, unless it was user defined, and the members and
+// exdata are kept. The original object is returned if it cannot be
+// simplified, such as for simple objects or for an invalid tolerance.
+func (o Object) Simplify(tolerance float64, method SimplifyMethod) Object {
+	if !(tolerance > 0) || !o.IsGeometry() || !o.valid() {
+		return o
+	}
+	return simplifyObject(o.expandAll(), tolerance, method)
+}
+
+// simplifyObject simplifies a valid object that has no compacted
+// coordinates.
+func simplifyObject(o Object, tolerance float64, method SimplifyMethod) Object {
+	if o.data[len(o.data)-1]>>3&1 == 0 {
+		return o // simple
+	}
+	g := o.geometryData()
+	if geomDepth(g.Type) == -1 {
+		s, _ := o.mapChildren(func(child Object) (Object, bool) {
+			return simplifyObject(child, tolerance, method), true
+		})
+		return s
+	}
+	// the rings of all of the polygons, or all of the lines, are a group
+	// that is simplified together
+	var groups [][]RingReader
+	switch g.Type {
+	default:
+		return o // points
+	case LineString:
+		r, _, _ := readRing(g.Data, g.Dims)
+		groups = [][]RingReader{{r}}
+	case MultiLineString, Polygon:
+		rings, _ := ringsIn(g.Data, g.Dims)
+		groups = [][]RingReader{rings}
+	case MultiPolygon:
+		n, data := readUint32(g.Data)
+		for i := 0; i < n; i++ {
+			var rings []RingReader
+			rings, data = ringsIn(data, g.Dims)
+			groups = append(groups, rings)
+		}
+	}
+	s := simplifier{tolerance: tolerance, method: method, dims: g.Dims,
+		min: baseMin, max: baseMax}
+	kept := s.simplifyGroups(groups, g.Type == Polygon || g.Type == MultiPolygon)
+	var out []byte
+	if g.Type == MultiPolygon {
+		out = append(out, g.Data[:4]...)
+	}
+	for _, lines := range kept {
+		if g.Type != LineString {
+			out = append(out, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint32(out[len(out)-4:], uint32(len(lines)))
+		}
+		for _, line := range lines {
+			out = s.appendLine(out, line)
+		}
+	}
+	c := o.parseComponents()
+	data := append([]byte{}, c.data[:len(c.data)-len(g.Data)]...)
+	c.data = append(data, out...)
+	if data[0]>>1&1 == 0 && s.count > 0 {
+		// recalculate the bbox from the remaining positions
+		c.bbox = make([]byte, len(c.bbox))
+		putBBox(c.bbox, g.Dims, s.min, s.max)
+	}
+	return c.reconstructObject()
+}
+
+type simplifier struct {
+	tolerance float64
+	method    SimplifyMethod
+	dims      int
+	count     int        // positions written
+	min, max  [3]float64 // bounds of the positions written
+}
+
+// ringsIn returns the rings of a [UINT32][RING...] block, and the remaining
+// data.
+func ringsIn(data []byte, dims int) ([]RingReader, []byte) {
+	var rings []RingReader
+	data, _ = forEachRingIn(data, dims, func(r RingReader) bool {
+		rings = append(rings, r)
+		return true
+	})
+	return rings, data
+}
+
+// simplifyGroups returns the positions that are kept of each line in the
+// groups. When preserving topology the tolerance of the lines that cross
+// is reduced until none of the lines, in any of the groups, cross.
+func (s *simplifier) simplifyGroups(groups [][]RingReader, closed bool) [][][]Position {
+	min := 2
+	if closed {
+		min = 4
+	}
+	var lines, kept [][]Position
+	var imps [][]float64
+	for _, rings := range groups {
+		for _, r := range rings {
+			line := make([]Position, r.Len())
+			for j := range line {
+				line[j] = r.At(j)
+			}
+			var imp []float64
+			if s.method&^PreserveTopology == Visvalingam {
+				imp = vwImportance(line)
+			} else {
+				imp = dpImportance(line)
+			}
+			lines = append(lines, line)
+			imps = append(imps, imp)
+			kept = append(kept, keepPositions(line, imp, s.tolerance, min))
+		}
+	}
+	if s.method&PreserveTopology != 0 {
+		tolerances := make([]float64, len(lines))
+		for i := range tolerances {
+			tolerances[i] = s.tolerance
+		}
+		for cross := linesCross(kept, closed); cross != nil; cross = linesCross(kept, closed) {
+			var reduced bool
+			for i, line := range lines {
+				if !cross[i] || len(kept[i]) == len(line) {
+					continue
+				}
+				reduced = true
+				tolerances[i] /= 2
+				if tolerances[i] < s.tolerance*1e-9 {
+					kept[i] = line
+				} else {
+					kept[i] = keepPositions(line, imps[i], tolerances[i], min)
+				}
+			}
+			if !reduced {
+				break // the original lines cross
+			}
+		}
+	}
+	out := make([][][]Position, len(groups))
+	for i, rings := range groups {
+		out[i], kept = kept[:len(rings)], kept[len(rings):]
+	}
+	return out
+}
+
+// appendLine appends a [UINT32][POSITIONS] ring and adds its positions to
+// the bounds.
+func (s *simplifier) appendLine(out []byte, line []Position) []byte {
+	out = append(out, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(out[len(out)-4:], uint32(len(line)))
+	for _, p := range line {
+		vals := [3]float64{p.X, p.Y, p.Z}
+		for i := 0; i < s.dims; i++ {
+			out = appendFloat64(out, vals[i])
+			s.min[i] = math.Min(s.min[i], vals[i])
+			s.max[i] = math.Max(s.max[i], vals[i])
+		}
+	}
+	s.count += len(line)
+	return out
+}
+
+// keepPositions returns the positions that have an importance greater than
+// the tolerance, but no fewer than min.
+func keepPositions(pts []Position, imp []float64, tolerance float64, min int) []Position {
+	if len(pts) <= min {
+		return pts
+	}
+	var n int
+	for _, v := range imp {
+		if v > tolerance {
+			n++
+		}
+	}
+	if n == len(pts) {
+		return pts
+	}
+	keep := make([]bool, len(pts))
+	for i, v := range imp {
+		keep[i] = v > tolerance
+	}
+	if n < min {
+		// keep the most important positions
+		order := make([]int, len(pts))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return imp[order[i]] > imp[order[j]]
+		})
+		for _, i := range order[:min] {
+			keep[i] = true
+		}
+		n = min
+	}
+	kept := make([]Position, 0, n)
+	for i, p := range pts {
+		if keep[i] {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// dpImportance returns the Douglas-Peucker distance at which each position
+// is removed. The first and last positions are never removed.
+func dpImportance(pts []Position) []float64 {
+	imp := make([]float64, len(pts))
+	if len(pts) == 0 {
+		return imp
+	}
+	imp[0], imp[len(pts)-1] = math.Inf(1), math.Inf(1)
+	type span struct {
+		i, j int
+		max  float64
+	}
+	stack := []span{{0, len(pts) - 1, math.Inf(1)}}
+	for len(stack) > 0 {
+		sp := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if sp.j-sp.i < 2 {
+			continue
+		}
+		k, dist := sp.i+1, -1.0
+		for i := sp.i + 1; i < sp.j; i++ {
+			d := segmentDistance(pts[i], pts[sp.i], pts[sp.j])
+			if math.IsNaN(d) {
+				// from infinite coordinates, keep the position
+				d = math.Inf(1)
+			}
+			if d > dist {
+				k, dist = i, d
+			}
+		}
+		// a position is never more important than the one that split it
+		imp[k] = math.Min(dist, sp.max)
+		stack = append(stack, span{sp.i, k, imp[k]}, span{k, sp.j, imp[k]})
+	}
+	return imp
+}
+
+// segmentDistance returns the planar distance from p to the segment ab.
+func segmentDistance(p, a, b Position) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	if dx != 0 || dy != 0 {
+		t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / (dx*dx + dy*dy)
+		if t > 1 {
+			a = b
+		} else if t > 0 {
+			a = Position{X: a.X + t*dx, Y: a.Y + t*dy}
+		}
+	}
+	return math.Hypot(p.X-a.X, p.Y-a.Y)
+}
+
+// vwImportance returns the Visvalingam-Whyatt effective area at which each
+// position is removed. The first and last positions are never removed.
+func vwImportance(pts []Position) []float64 {
+	n := len(pts)
+	imp := make([]float64, n)
+	if n == 0 {
+		return imp
+	}
+	imp[0], imp[n-1] = math.Inf(1), math.Inf(1)
+	if n < 3 {
+		return imp
+	}
+	prev := make([]int, n)
+	next := make([]int, n)
+	h := &vwHeap{area: imp, index: make([]int, n)}
+	for i := 1; i < n-1; i++ {
+		prev[i], next[i] = i-1, i+1
+		imp[i] = triangleArea(pts[i-1], pts[i], pts[i+1])
+		h.items = append(h.items, i)
+		h.index[i] = len(h.items) - 1
+	}
+	heap.Init(h)
+	var max float64
+	for h.Len() > 0 {
+		i := heap.Pop(h).(int)
+		// a position is never less important than one removed before it
+		max = math.Max(max, imp[i])
+		imp[i] = max
+		p, q := prev[i], next[i]
+		next[p], prev[q] = q, p
+		if p > 0 {
+			imp[p] = triangleArea(pts[prev[p]], pts[p], pts[q])
+			heap.Fix(h, h.index[p])
+		}
+		if q < n-1 {
+			imp[q] = triangleArea(pts[p], pts[q], pts[next[q]])
+			heap.Fix(h, h.index[q])
+		}
+	}
+	return imp
+}
+
+// triangleArea returns the planar area of the triangle abc.
+func triangleArea(a, b, c Position) float64 {
+	return math.Abs((b.X-a.X)*(c.Y-a.Y)-(c.X-a.X)*(b.Y-a.Y)) / 2
+}
+
+// vwHeap is a min heap of position indexes ordered by their area.
+type vwHeap struct {
+	items []int
+	area  []float64
+	index []int // heap index of each position
+}
+
+func (h *vwHeap) Len() int           { return len(h.items) }
+func (h *vwHeap) Less(i, j int) bool { return h.area[h.items[i]] < h.area[h.items[j]] }
+func (h *vwHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i]] = i
+	h.index[h.items[j]] = j
+}
+func (h *vwHeap) Push(x interface{}) {
+	h.index[x.(int)] = len(h.items)
+	h.items = append(h.items, x.(int))
+}
+func (h *vwHeap) Pop() interface{} {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
+
+// lineSegment is the segment from position k to k+1 of a line, and its
+// bounds.
+type lineSegment struct {
+	line, k  int
+	min, max Position
+}
+
+// linesCross returns which of the lines have a segment that intersects
+// another segment, other than the neighboring segments of a line that share
+// a position. Returns nil if none of the lines cross. The segments are swept
+// from west to east, and only the segments with overlapping bounds are
+// tested.
+func linesCross(lines [][]Position, closed bool) []bool {
+	var segs []lineSegment
+	for i, line := range lines {
+		for k := 1; k < len(line); k++ {
+			a, b := line[k-1], line[k]
+			segs = append(segs, lineSegment{line: i, k: k - 1,
+				min: Position{X: math.Min(a.X, b.X), Y: math.Min(a.Y, b.Y)},
+				max: Position{X: math.Max(a.X, b.X), Y: math.Max(a.Y, b.Y)},
+			})
+		}
+	}
+	sort.Slice(segs, func(i, j int) bool {
+		return segs[i].min.X < segs[j].min.X
+	})
+	var cross []bool
+	for i, a := range segs {
+		for _, b := range segs[i+1:] {
+			if b.min.X > a.max.X {
+				break
+			}
+			if b.min.Y > a.max.Y || b.max.Y < a.min.Y {
+				continue
+			}
+			if a.line == b.line {
+				last := len(lines[a.line]) - 2
+				if d := a.k - b.k; d == 1 || d == -1 ||
+					closed && (d == last || d == -last) {
+					continue // neighbors, or the first and last segments of a ring
+				}
+			}
+			x, y := a, b
+			if y.line < x.line || y.line == x.line && y.k < x.k {
+				x, y = b, a // test in the order of the lines
+			}
+			lx, ly := lines[x.line], lines[y.line]
+			if segmentsIntersect(lx[x.k], lx[x.k+1], ly[y.k], ly[y.k+1]) {
+				if cross == nil {
+					cross = make([]bool, len(lines))
+				}
+				cross[a.line], cross[b.line] = true, true
+			}
+		}
+	}
+	return cross
+}
@@ -0,0 +1,219 @@
+package geobin
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimplifyLine(t *testing.T) {
+	line := ParseJSON(`{"type":"LineString","coordinates":[[0,0],[1,0.1],[2,-0.1],[3,5],[4,6],[5,7],[6,8.1],[7,9],[8,9],[9,9],[10,10]]}`)
+	assert.Equal(t, `{"type":"LineString","coordinates":[[0,0],[2,-0.1],[3,5],[7,9],[9,9],[10,10]]}`,
+		line.Simplify(0.5, DouglasPeucker).JSON())
+	assert.Equal(t, `{"type":"LineString","coordinates":[[0,0],[10,10]]}`,
+		line.Simplify(100, DouglasPeucker).JSON())
+	// only the collinear positions are removed
+	assert.Equal(t, `{"type":"LineString","coordinates":[[0,0],[1,0.1],[2,-0.1],[3,5],[5,7],[6,8.1],[7,9],[9,9],[10,10]]}`,
+		line.Simplify(0.01, DouglasPeucker).JSON())
+	assert.Equal(t, `{"type":"LineString","coordinates":[[0,0],[2,-0.1],[3,5],[7,9],[9,9],[10,10]]}`,
+		line.Simplify(0.5, Visvalingam).JSON())
+	assert.Equal(t, `{"type":"LineString","coordinates":[[0,0],[3,5],[10,10]]}`,
+		line.Simplify(6, Visvalingam).JSON())
+	assert.Equal(t, `{"type":"LineString","coordinates":[[0,0],[10,10]]}`,
+		line.Simplify(1000, Visvalingam).JSON())
+
+	// 3D positions and the bbox is recalculated
+	line3 := ParseJSON(`{"type":"LineString","coordinates":[[0,0,1],[5,-1,2],[7.5,-0.45,3],[10,0,4]]}`)
+	s := line3.Simplify(0.1, DouglasPeucker)
+	assert.Equal(t, `{"type":"LineString","coordinates":[[0,0,1],[5,-1,2],[10,0,4]]}`, s.JSON())
+	min, max := s.Rect(nil)
+	assert.Equal(t, [3]float64{0, -1, 1}, min)
+	assert.Equal(t, [3]float64{10, 0, 4}, max)
+
+	// invalid and unsupported
+	assert.Equal(t, line, line.Simplify(0, DouglasPeucker))
+	assert.Equal(t, line, line.Simplify(math.NaN(), DouglasPeucker))
+	assert.Equal(t, Make2DRect(1, 2, 3, 4), Make2DRect(1, 2, 3, 4).Simplify(10, DouglasPeucker))
+	mp := ParseJSON(`{"type":"MultiPoint","coordinates":[[0,0],[0,0.1],[0,0.2]]}`)
+	assert.Equal(t, mp, mp.Simplify(1, DouglasPeucker))
+	assert.Equal(t, MakeString("hello"), MakeString("hello").Simplify(1, DouglasPeucker))
+}
+
+func TestSimplifyPolygon(t *testing.T) {
+	poly := ParseJSON(`{"type":"Polygon","coordinates":[
+		[[0,0],[5,0.1],[10,0],[10.1,5],[10,10],[5,9.9],[0,10],[-0.1,5],[0,0]],
+		[[4,4],[6,4],[6,6],[4,6],[4,4]]]}`)
+	s := poly.Simplify(0.5, DouglasPeucker)
+	assert.Equal(t, `{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[4,4],[6,4],[6,6],[4,6],[4,4]]]}`, s.JSON())
+	// rings keep four positions
+	s = poly.Simplify(100, DouglasPeucker)
+	s.ForEachRing(func(r RingReader) bool {
+		assert.Equal(t, 4, r.Len())
+		assert.Equal(t, r.At(0), r.At(r.Len()-1))
+		return true
+	})
+	s = poly.Simplify(1000, Visvalingam)
+	s.ForEachRing(func(r RingReader) bool {
+		assert.Equal(t, 4, r.Len())
+		assert.Equal(t, r.At(0), r.At(r.Len()-1))
+		return true
+	})
+	assert.NoError(t, Validate(s.Binary()))
+
+	// members, exdata, and user defined bboxes are kept
+	f := ParseJSON(`{"type":"Feature","bbox":[-1,-1,11,11],"id":1,"properties":{"a":1},"geometry":` + poly.JSON() + `}`)
+	f = f.SetExData([]byte("hello"))
+	s = f.Simplify(0.5, DouglasPeucker)
+	assert.Equal(t, `{"type":"Feature","bbox":[-1,-1,11,11],"geometry":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[4,4],[6,4],[6,6],[4,6],[4,4]]]},"id":1,"properties":{"a":1}}`, s.JSON())
+	assert.Equal(t, "hello", string(s.ExData()))
+	fc := ParseJSON(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":` + poly.JSON() + `}]}`)
+	min, max := fc.Simplify(0.5, DouglasPeucker).Rect(nil)
+	assert.Equal(t, [3]float64{0, 0, 0}, min)
+	assert.Equal(t, [3]float64{10, 10, 0}, max)
+	// compacted objects are expanded
+	assert.Equal(t, poly.Simplify(0.5, DouglasPeucker).JSON(), poly.Compact(1e-7).Simplify(0.5, DouglasPeucker).JSON())
+}
+
+func TestSimplifyTopology(t *testing.T) {
+	// a hole near the edge of the exterior that would cross it
+	poly := ParseJSON(`{"type":"Polygon","coordinates":[
+		[[0,0],[5,-3],[10,0],[10,10],[0,10],[0,0]],
+		[[4,-1],[6,-1],[5,1],[4,-1]]]}`)
+	s := poly.Simplify(4, DouglasPeucker)
+	var rings [][]Position
+	s.ForEachRing(func(r RingReader) bool {
+		var ring []Position
+		r.ForEachPosition(func(p Position) bool {
+			ring = append(ring, p)
+			return true
+		})
+		rings = append(rings, ring)
+		return true
+	})
+	assert.NotNil(t, linesCross(rings, true))
+	s = poly.Simplify(4, DouglasPeucker|PreserveTopology)
+	rings = rings[:0]
+	s.ForEachRing(func(r RingReader) bool {
+		var ring []Position
+		r.ForEachPosition(func(p Position) bool {
+			ring = append(ring, p)
+			return true
+		})
+		rings = append(rings, ring)
+		return true
+	})
+	assert.Nil(t, linesCross(rings, true))
+	assert.Equal(t, poly.JSON(), s.JSON())
+
+	// a line that would cross another line
+	lines := ParseJSON(`{"type":"MultiLineString","coordinates":[[[0,0],[4,0],[5,3],[6,0],[10,0]],[[5,-1],[5,1]]]}`)
+	s = lines.Simplify(4, DouglasPeucker)
+	assert.Equal(t, `{"type":"MultiLineString","coordinates":[[[0,0],[10,0]],[[5,-1],[5,1]]]}`, s.JSON())
+	s = lines.Simplify(4, DouglasPeucker|PreserveTopology)
+	assert.Equal(t, lines.JSON(), s.JSON())
+
+	// a polygon that would cross another polygon
+	mpoly := ParseJSON(`{"type":"MultiPolygon","coordinates":[
+		[[[0,0],[5,3],[10,0],[10,10],[0,10],[0,0]]],
+		[[[4,-1],[6,-1],[5,1],[4,-1]]]]}`)
+	rings = rings[:0]
+	mpoly.Simplify(4, DouglasPeucker).ForEachRing(func(r RingReader) bool {
+		var ring []Position
+		r.ForEachPosition(func(p Position) bool {
+			ring = append(ring, p)
+			return true
+		})
+		rings = append(rings, ring)
+		return true
+	})
+	assert.NotNil(t, linesCross(rings, true))
+	assert.Equal(t, mpoly.JSON(), mpoly.Simplify(4, DouglasPeucker|PreserveTopology).JSON())
+
+	// infinite coordinates have NaN distances
+	inf := ParseJSON(`{"type":"LineString","coordinates":[[1e400,0],[1e400,1],[0,5]]}`)
+	assert.NoError(t, Validate(inf.Binary()))
+	for _, method := range []SimplifyMethod{DouglasPeucker, Visvalingam, DouglasPeucker | PreserveTopology} {
+		assert.NotPanics(t, func() { inf.Simplify(1, method) })
+	}
+	assert.Equal(t, 3, inf.Simplify(1, DouglasPeucker).PositionCount())
+}
+
+func TestLinesCross(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 1000; i++ {
+		closed := rnd.Intn(2) == 0
+		lines := make([][]Position, 1+rnd.Intn(3))
+		for j := range lines {
+			for k := 2 + rnd.Intn(5); k > 0; k-- {
+				lines[j] = append(lines[j], P(float64(rnd.Intn(10)), float64(rnd.Intn(10))))
+			}
+			if closed {
+				lines[j] = append(lines[j], lines[j][0])
+			}
+		}
+		// test every pair of segments
+		expect := make([]bool, len(lines))
+		var any bool
+		for a, la := range lines {
+			for b := a; b < len(lines); b++ {
+				lb := lines[b]
+				for k := 1; k < len(la); k++ {
+					start := 1
+					if a == b {
+						start = k + 2
+					}
+					for l := start; l < len(lb); l++ {
+						if a == b && closed && k == 1 && l == len(lb)-1 {
+							continue
+						}
+						if segmentsIntersect(la[k-1], la[k], lb[l-1], lb[l]) {
+							expect[a], expect[b], any = true, true, true
+						}
+					}
+				}
+			}
+		}
+		if !any {
+			expect = nil
+		}
+		assert.Equal(t, expect, linesCross(lines, closed), "seed %d %v", seed, lines)
+	}
+}
+
+func BenchmarkSimplifyTopology(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	var ring []Position
+	for i := 0; i < 20000; i++ {
+		a := float64(i) / 20000 * 2 * math.Pi
+		r := 10 + rnd.Float64()*0.01
+		ring = append(ring, P(math.Cos(a)*r, math.Sin(a)*r))
+	}
+	ring = append(ring, ring[0])
+	poly := MakePolygon(ring)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		poly.Simplify(1e-4, DouglasPeucker|PreserveTopology)
+	}
+}
+
+func TestSimplifyRandom(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 2000; i++ {
+		o := randTestObject(rnd)
+		for _, method := range []SimplifyMethod{DouglasPeucker, Visvalingam, DouglasPeucker | PreserveTopology} {
+			s := o.Simplify(rnd.Float64()*10, method)
+			if err := Validate(s.Binary()); err != nil {
+				t.Fatalf("seed %d: %v\n%s", seed, err, o.JSON())
+			}
+			if s.PositionCount() > o.PositionCount() {
+				t.Fatalf("seed %d: more positions\n%s", seed, o.JSON())
+			}
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package geobin
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	errInvalidJSON     = errors.New("invalid json")
+	errInvalidMember   = errors.New("invalid geojson member type")
+	errInvalidBBox     = errors.New("invalid geojson bbox")
+	errInvalidPosition = errors.New("invalid geojson position")
+	errOutOfRange      = errors.New("invalid geojson position, out of range")
+	errMixedDims       = errors.New("invalid geojson position, mismatched dimensions")
+	errShortLine       = errors.New("invalid geojson line, fewer than two positions")
+	errShortRing       = errors.New("invalid geojson ring, fewer than four positions")
+	errUnclosedRing    = errors.New("invalid geojson ring, not closed")
+)
+
+// strictJSON checks that the GeoJSON follows RFC 7946. Returns a ParseError
+// with the path of the invalid member.
+func strictJSON(json string) error {
+	if !gjson.Valid(json) {
+		return parseError("", errInvalidJSON)
+	}
+	return strictObject(gjson.Parse(json), "", "")
+}
+
+// strictObject checks a GeoJSON object. The expect param is the required
+// kind of object, "geometry" or "Feature", or empty for any.
+func strictObject(json gjson.Result, path, expect string) error {
+	if !json.IsObject() {
+		return parseError(path, errInvalidMember)
+	}
+	typ := json.Get("type")
+	if typ.Type != gjson.String {
+		return parseError(joinPath(path, "type"), errInvalidMember)
+	}
+	var depth int
+	switch typ.Str {
+	case "Point":
+		depth = 0
+	case "MultiPoint", "LineString":
+		depth = 1
+	case "MultiLineString", "Polygon":
+		depth = 2
+	case "MultiPolygon":
+		depth = 3
+	case "GeometryCollection":
+		depth = -1
+	case "Feature", "FeatureCollection":
+		if expect == "geometry" {
+			return parseError(joinPath(path, "type"), errInvalidType)
+		}
+		depth = -1
+	default:
+		return parseError(joinPath(path, "type"), errInvalidType)
+	}
+	if expect == "Feature" && typ.Str != "Feature" {
+		return parseError(joinPath(path, "type"), errInvalidType)
+	}
+	if bbox := json.Get("bbox"); bbox.Exists() {
+		if err := strictBBox(bbox, joinPath(path, "bbox")); err != nil {
+			return err
+		}
+	}
+	if depth != -1 {
+		coords := json.Get("coordinates")
+		if !coords.IsArray() {
+			return parseError(joinPath(path, "coordinates"), errInvalidMember)
+		}
+		var dims int
+		return strictCoords(typ.Str, coords, joinPath(path, "coordinates"), depth, &dims)
+	}
+	switch typ.Str {
+	case "GeometryCollection":
+		return strictChildren(json.Get("geometries"), joinPath(path, "geometries"), "geometry")
+	case "FeatureCollection":
+		return strictChildren(json.Get("features"), joinPath(path, "features"), "Feature")
+	}
+	if id := json.Get("id"); id.Exists() && id.Type != gjson.String && id.Type != gjson.Number {
+		return parseError(joinPath(path, "id"), errInvalidMember)
+	}
+	if props := json.Get("properties"); props.Exists() && props.Type != gjson.Null && !props.IsObject() {
+		return parseError(joinPath(path, "properties"), errInvalidMember)
+	}
+	geom := json.Get("geometry")
+	if !geom.IsObject() {
+		// null geometries are not supported
+		return parseError(joinPath(path, "geometry"), errInvalidGeometry)
+	}
+	return strictObject(geom, joinPath(path, "geometry"), "geometry")
+}
+
+// strictChildren checks the "geometries" or "features" of a collection.
+func strictChildren(children gjson.Result, path, expect string) error {
+	if !children.IsArray() {
+		return parseError(path, errInvalidMember)
+	}
+	var err error
+	var i int
+	children.ForEach(func(_, child gjson.Result) bool {
+		err = strictObject(child, joinPath(path, strconv.Itoa(i)), expect)
+		i++
+		return err == nil
+	})
+	return err
+}
+
+// strictCoords checks coordinates to the depth, where zero is a position.
+// The dims are set by the first position and every other position must
+// match.
+func strictCoords(typ string, coords gjson.Result, path string, depth int, dims *int) error {
+	if depth == 0 {
+		return strictPosition(coords, path, dims)
+	}
+	if !coords.IsArray() {
+		return parseError(path, errInvalidMember)
+	}
+	var err error
+	var n int
+	var first, last gjson.Result
+	coords.ForEach(func(_, val gjson.Result) bool {
+		err = strictCoords(typ, val, joinPath(path, strconv.Itoa(n)), depth-1, dims)
+		if n == 0 {
+			first = val
+		}
+		last = val
+		n++
+		return err == nil
+	})
+	if err != nil || depth != 1 {
+		return err
+	}
+	switch typ {
+	case "LineString", "MultiLineString":
+		if n < 2 {
+			return parseError(path, errShortLine)
+		}
+	case "Polygon", "MultiPolygon":
+		if n < 4 {
+			return parseError(path, errShortRing)
+		}
+		if !samePosition(first, last) {
+			return parseError(path, errUnclosedRing)
+		}
+	}
+	return nil
+}
+
+// strictPosition checks a position.
+func strictPosition(pos gjson.Result, path string, dims *int) error {
+	if !pos.IsArray() {
+		return parseError(path, errInvalidMember)
+	}
+	var err error
+	var n int
+	pos.ForEach(func(_, val gjson.Result) bool {
+		err = strictNumber(val, joinPath(path, strconv.Itoa(n)), n)
+		n++
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if n < 2 {
+		return parseError(path, errInvalidPosition)
+	}
+	if *dims == 0 {
+		*dims = n
+	} else if n != *dims {
+		return parseError(path, errMixedDims)
+	}
+	return nil
+}
+
+// strictNumber checks a coordinate value, where the axis is 0 for the
+// longitude and 1 for the latitude.
+func strictNumber(val gjson.Result, path string, axis int) error {
+	if val.Type != gjson.Number {
+		return parseError(path, errInvalidMember)
+	}
+	f := val.Float()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return parseError(path, errInvalidPosition)
+	}
+	if (axis == 0 && (f < -180 || f > 180)) || (axis == 1 && (f < -90 || f > 90)) {
+		return parseError(path, errOutOfRange)
+	}
+	return nil
+}
+
+// strictBBox checks a 2D or 3D bbox. The west may be greater than the east
+// for bboxes that cross the antimeridian.
+func strictBBox(bbox gjson.Result, path string) error {
+	if !bbox.IsArray() {
+		return parseError(path, errInvalidMember)
+	}
+	var vals []float64
+	var err error
+	bbox.ForEach(func(_, val gjson.Result) bool {
+		if len(vals) == 6 {
+			err = parseError(path, errInvalidBBox)
+			return false
+		}
+		err = strictNumber(val, joinPath(path, strconv.Itoa(len(vals))), -1)
+		vals = append(vals, val.Float())
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(vals) != 4 && len(vals) != 6 {
+		return parseError(path, errInvalidBBox)
+	}
+	dims := len(vals) / 2
+	for i := 0; i < dims; i++ {
+		lo, hi := vals[i], vals[dims+i]
+		switch {
+		case i == 0 && (lo < -180 || lo > 180 || hi < -180 || hi > 180),
+			i == 1 && (lo < -90 || hi > 90):
+			return parseError(path, errOutOfRange)
+		case i > 0 && lo > hi:
+			return parseError(path, errInvalidBBox)
+		}
+	}
+	return nil
+}
+
+// samePosition returns true if two positions have the same values.
+func samePosition(a, b gjson.Result) bool {
+	av, bv := a.Array(), b.Array()
+	if len(av) != len(bv) {
+		return false
+	}
+	for i := range av {
+		if av[i].Float() != bv[i].Float() {
+			return false
+		}
+	}
+	return true
+}
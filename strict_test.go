@@ -0,0 +1,79 @@
+package geobin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrict(t *testing.T) {
+	opts := &ParseJSONOptions{Strict: true}
+	for _, js := range []string{
+		`{"type":"Point","coordinates":[-180,90]}`,
+		`{"type":"Point","coordinates":[1,2,3],"bbox":[1,2,3,1,2,3]}`,
+		`{"type":"MultiPoint","coordinates":[]}`,
+		`{"type":"LineString","coordinates":[[1,2],[3,4]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`,
+		`{"type":"Polygon","coordinates":[]}`,
+		`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`,
+		`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2,3]},{"type":"Point","coordinates":[1,2]}]}`,
+		`{"type":"Feature","id":"a","properties":null,"geometry":{"type":"Point","coordinates":[1,2]},"bbox":[170,-10,-170,10]}`,
+		`{"type":"FeatureCollection","features":[]}`,
+	} {
+		o, err := ParseJSONWithOptions(js, opts)
+		if assert.NoError(t, err, js) {
+			assert.Equal(t, ParseJSON(js).Binary(), o.Binary(), js)
+		}
+	}
+	var tests = []struct {
+		js   string
+		err  error
+		path string
+	}{
+		{`{"type":"Point","coordinates":[1,2]`, errInvalidJSON, ""},
+		{`[]`, errInvalidMember, ""},
+		{`{"type":1,"coordinates":[1,2]}`, errInvalidMember, "type"},
+		{`{"type":"Pointy","coordinates":[1,2]}`, errInvalidType, "type"},
+		{`{"type":"Point","coordinates":[1]}`, errInvalidPosition, "coordinates"},
+		{`{"type":"Point","coordinates":{}}`, errInvalidMember, "coordinates"},
+		{`{"type":"Point","coordinates":[1,"2"]}`, errInvalidMember, "coordinates.1"},
+		{`{"type":"Point","coordinates":[1,1e999]}`, errInvalidPosition, "coordinates.1"},
+		{`{"type":"Point","coordinates":[181,0]}`, errOutOfRange, "coordinates.0"},
+		{`{"type":"Point","coordinates":[0,-91]}`, errOutOfRange, "coordinates.1"},
+		{`{"type":"Point","coordinates":[1,2],"bbox":[1,2,3]}`, errInvalidBBox, "bbox"},
+		{`{"type":"Point","coordinates":[1,2],"bbox":[1,3,1,2]}`, errInvalidBBox, "bbox"},
+		{`{"type":"Point","coordinates":[1,2],"bbox":[1,2,1,95]}`, errOutOfRange, "bbox"},
+		{`{"type":"Point","coordinates":[1,2],"bbox":"1,2,1,2"}`, errInvalidMember, "bbox"},
+		{`{"type":"MultiPoint","coordinates":[[1,2],[1,2,3]]}`, errMixedDims, "coordinates.1"},
+		{`{"type":"LineString","coordinates":[[1,2]]}`, errShortLine, "coordinates"},
+		{`{"type":"MultiLineString","coordinates":[[[1,2],[3,4]],[]]}`, errShortLine, "coordinates.1"},
+		{`{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`, errShortRing, "coordinates.0"},
+		{`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`, errUnclosedRing, "coordinates.0"},
+		{`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],[[[0,0],[1,0],[1,1],[0,1]]]]}`, errUnclosedRing, "coordinates.1.0"},
+		{`{"type":"GeometryCollection","geometries":[{"type":"Feature"}]}`, errInvalidType, "geometries.0.type"},
+		{`{"type":"GeometryCollection"}`, errInvalidMember, "geometries"},
+		{`{"type":"Feature","id":[],"geometry":{"type":"Point","coordinates":[1,2]}}`, errInvalidMember, "id"},
+		{`{"type":"Feature","properties":1,"geometry":{"type":"Point","coordinates":[1,2]}}`, errInvalidMember, "properties"},
+		{`{"type":"Feature","geometry":null}`, errInvalidGeometry, "geometry"},
+		{`{"type":"FeatureCollection","features":[{"type":"Point","coordinates":[1,2]}]}`, errInvalidType, "features.0.type"},
+		{`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}},` +
+			`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]],[[0,0],[1,0],[1,1]]]}}]}`,
+			errShortRing, "features.1.geometry.coordinates.1"},
+		{`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"LineString","coordinates":[[0,0],[1,0,0]]}}]}`,
+			errMixedDims, "features.0.geometry.coordinates.1"},
+	}
+	for _, test := range tests {
+		_, err := ParseJSONWithOptions(test.js, opts)
+		if !assert.Error(t, err, test.js) {
+			continue
+		}
+		assert.True(t, errors.Is(err, test.err), "%s: %v", test.js, err)
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), test.js) {
+			assert.Equal(t, test.path, perr.Path, test.js)
+		}
+		// lenient by default
+		ParseJSON(test.js)
+	}
+}
@@ -0,0 +1,121 @@
+package geobin
+
+import (
+	"errors"
+	"math"
+)
+
+// maxTileZoom is the deepest zoom of a tile.
+const maxTileZoom = 30
+
+var (
+	errInvalidTile    = errors.New("invalid tile")
+	errInvalidQuadkey = errors.New("invalid quadkey")
+)
+
+// Tile is an XYZ slippy map tile. X and Y are from 0 to 2^Z-1, where 0,0 is
+// the north-west tile.
+type Tile struct {
+	Z, X, Y int
+}
+
+// TileBBox returns the longitude and latitude bbox of the z/x/y tile, in Web
+// Mercator.
+func TileBBox(z, x, y int) BBox {
+	n := math.Exp2(float64(z))
+	return BBox{
+		Min: Position{X: tileLon(float64(x), n), Y: tileLat(float64(y+1), n)},
+		Max: Position{X: tileLon(float64(x+1), n), Y: tileLat(float64(y), n)},
+	}
+}
+
+// validTile returns true if the tile is at a zoom from 0 to 30, and its X
+// and Y are in range.
+func validTile(z, x, y int) bool {
+	return z >= 0 && z <= maxTileZoom && x >= 0 && y >= 0 && x < 1<<uint(z) && y < 1<<uint(z)
+}
+
+// QuadkeyEncode returns the Bing Maps quadkey of the z/x/y tile, which has
+// one digit per zoom. The quadkey of the 0/0/0 tile is empty.
+func QuadkeyEncode(z, x, y int) (string, error) {
+	if !validTile(z, x, y) {
+		return "", errInvalidTile
+	}
+	key := make([]byte, z)
+	for i := 0; i < z; i++ {
+		shift := uint(z - i - 1)
+		key[i] = '0' + byte(x>>shift&1) + byte(y>>shift&1)<<1
+	}
+	return string(key), nil
+}
+
+// QuadkeyDecode returns the z/x/y tile of a Bing Maps quadkey.
+func QuadkeyDecode(key string) (z, x, y int, err error) {
+	if len(key) > maxTileZoom {
+		return 0, 0, 0, errInvalidQuadkey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '0' || key[i] > '3' {
+			return 0, 0, 0, errInvalidQuadkey
+		}
+		d := int(key[i] - '0')
+		x = x<<1 | d&1
+		y = y<<1 | d>>1
+	}
+	return len(key), x, y, nil
+}
+
+// TileCover returns the tiles at the zoom that intersect the object, in
+// quadkey order. The tiles are found by descending from the 0/0/0 tile into
+// the tiles that overlap the bbox of the object, and that intersect the
+// object. The tiles of the north and south edges extend to the poles, so
+// positions beyond the Web Mercator bounds are in those tiles. Returns nil
+// for an invalid zoom, which must be from 0 to 30, or for an object without
+// positions.
+func (o Object) TileCover(zoom int) []Tile {
+	rect, ok := objectRect(o)
+	if !ok || zoom < 0 || zoom > maxTileZoom {
+		return nil
+	}
+	var tiles []Tile
+	tileCover(o, rect, Tile{}, zoom, &tiles)
+	return tiles
+}
+
+func tileCover(o Object, rect indexRect, t Tile, zoom int, tiles *[]Tile) {
+	bbox := TileBBox(t.Z, t.X, t.Y)
+	if t.Y == 0 {
+		bbox.Max.Y = 90
+	}
+	if t.Y == 1<<uint(t.Z)-1 {
+		bbox.Min.Y = -90
+	}
+	if !rect.intersects(bboxRect(bbox)) || !tileIntersects(o, bbox) {
+		return
+	}
+	if t.Z == zoom {
+		*tiles = append(*tiles, t)
+		return
+	}
+	for i := 0; i < 4; i++ {
+		child := Tile{Z: t.Z + 1, X: t.X<<1 | i&1, Y: t.Y<<1 | i>>1}
+		tileCover(o, rect, child, zoom, tiles)
+	}
+}
+
+// tileIntersects returns true if the object intersects the bbox. The
+// children of collections are tested one at a time.
+func tileIntersects(o Object, bbox BBox) bool {
+	switch o.GeometryType() {
+	case FeatureCollection, GeometryCollection:
+		var hit bool
+		o.ForEachChild(func(_ int, child Object) bool {
+			hit = tileIntersects(child, bbox)
+			return !hit
+		})
+		return hit
+	case Feature:
+		return tileIntersects(o.FeatureGeometry(), bbox)
+	}
+	return o.IntersectsBBox(bbox)
+}
@@ -0,0 +1,114 @@
+package geobin
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuadkey(t *testing.T) {
+	key, err := QuadkeyEncode(3, 3, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, "213", key)
+	z, x, y, err := QuadkeyDecode("213")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 3, 5}, []int{z, x, y})
+
+	key, err = QuadkeyEncode(0, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "", key)
+	z, x, y, err = QuadkeyDecode("")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 0, 0}, []int{z, x, y})
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 1000; i++ {
+		z := rnd.Intn(maxTileZoom + 1)
+		x, y := rnd.Intn(1<<uint(z)), rnd.Intn(1<<uint(z))
+		key, err := QuadkeyEncode(z, x, y)
+		assert.Nil(t, err)
+		assert.Len(t, key, z)
+		z2, x2, y2, err := QuadkeyDecode(key)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{z, x, y}, []int{z2, x2, y2})
+	}
+
+	// invalid
+	for _, tile := range [][3]int{{-1, 0, 0}, {31, 0, 0}, {2, 4, 0}, {2, 0, 4}, {2, -1, 0}} {
+		_, err := QuadkeyEncode(tile[0], tile[1], tile[2])
+		assert.Equal(t, errInvalidTile, err)
+	}
+	for _, key := range []string{"4", "01a", "0123012301230123012301230123012"} {
+		_, _, _, err := QuadkeyDecode(key)
+		assert.Equal(t, errInvalidQuadkey, err)
+	}
+}
+
+func TestTileBBox(t *testing.T) {
+	bbox := TileBBox(0, 0, 0)
+	assertNearPosition(t, P(-180, -maxLatitude), bbox.Min)
+	assertNearPosition(t, P(180, maxLatitude), bbox.Max)
+	bbox = TileBBox(1, 1, 0)
+	assertNearPosition(t, P(0, 0), bbox.Min)
+	assertNearPosition(t, P(180, maxLatitude), bbox.Max)
+	bbox = TileBBox(2, 0, 3)
+	assertNearPosition(t, P(-180, -maxLatitude), bbox.Min)
+	assertNearPosition(t, P(-90, tileLat(3, 4)), bbox.Max)
+}
+
+func TestTileCover(t *testing.T) {
+	assert.Equal(t, []Tile{{0, 0, 0}}, Make2DPoint(10, 10).TileCover(0))
+	assert.Equal(t, []Tile{{1, 1, 0}}, Make2DPoint(10, 10).TileCover(1))
+	assert.Equal(t, []Tile{{10, 540, 483}}, Make2DPoint(10.1, 10.1).TileCover(10))
+	// beyond the Web Mercator bounds
+	assert.Equal(t, []Tile{{2, 2, 0}}, Make2DPoint(10, 89).TileCover(2))
+	assert.Equal(t, []Tile{{2, 2, 3}}, Make2DPoint(10, -89).TileCover(2))
+	// on the edge of four tiles
+	assert.Equal(t, []Tile{{1, 0, 0}, {1, 1, 0}, {1, 0, 1}, {1, 1, 1}}, Make2DPoint(0, 0).TileCover(1))
+
+	// the bbox of the line overlaps 2/1/0, but not the line itself
+	line := ParseJSON(`{"type":"LineString","coordinates":[[-170,80],[-10,-10]]}`)
+	assert.Equal(t, []Tile{{2, 0, 0}, {2, 0, 1}, {2, 1, 1}, {2, 1, 2}}, line.TileCover(2))
+	assert.Equal(t, line.TileCover(2),
+		ParseJSON(`{"type":"Feature","geometry":`+line.JSON()+`,"properties":null}`).TileCover(2))
+
+	coll := ParseJSON(`{"type":"GeometryCollection","geometries":[
+		{"type":"Point","coordinates":[100,-40]},{"type":"Point","coordinates":[-100,40]}]}`)
+	assert.Equal(t, []Tile{{1, 0, 0}, {1, 1, 1}}, coll.TileCover(1))
+	fc := ParseJSON(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[100,-40]},"properties":null},
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[-100,40]},"properties":null}]}`)
+	assert.Equal(t, []Tile{{1, 0, 0}, {1, 1, 1}}, fc.TileCover(1))
+
+	// compare with every tile
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 20; i++ {
+		x, y := rnd.Float64()*300-150, rnd.Float64()*140-70
+		w, h := ftoa(x+rnd.Float64()*30), ftoa(y+rnd.Float64()*15)
+		poly := ParseJSON(`{"type":"Polygon","coordinates":[[[` + ftoa(x) + `,` + ftoa(y) + `],[` + w + `,` +
+			ftoa(y) + `],[` + w + `,` + h + `],[` + ftoa(x) + `,` + ftoa(y) + `]]]}`)
+		zoom := 4
+		var expect []Tile
+		for key := 0; key < 1<<uint(zoom*2); key++ {
+			var tile Tile
+			tile.Z = zoom
+			for j := zoom - 1; j >= 0; j-- {
+				d := key >> uint(j*2) & 3
+				tile.X, tile.Y = tile.X<<1|d&1, tile.Y<<1|d>>1
+			}
+			if poly.IntersectsBBox(TileBBox(tile.Z, tile.X, tile.Y)) {
+				expect = append(expect, tile)
+			}
+		}
+		assert.Equal(t, expect, poly.TileCover(zoom), "seed %d", seed)
+	}
+
+	// invalid
+	assert.Nil(t, Make2DPoint(10, 10).TileCover(-1))
+	assert.Nil(t, Make2DPoint(10, 10).TileCover(31))
+	assert.Nil(t, MakeString("hello").TileCover(1))
+	assert.Nil(t, ParseJSON(`{"type":"LineString","coordinates":[]}`).TileCover(1))
+}
@@ -0,0 +1,174 @@
+package geobin
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// webMercatorRadius is the radius of the sphere used by EPSG:3857.
+const webMercatorRadius = 6378137
+
+// Transform returns a copy of the object with every position replaced by
+// fn. The Z of a 2D position is zero and is not kept. The bbox is
+// recalculated, and an exported bbox stays exported. The members and exdata
+// are kept. A simple rect is converted into the Polygon, or the MultiPolygon
+// for 3D, that it represents.
+func (o Object) Transform(fn func(p Position) Position) Object {
+	if !o.IsGeometry() || !o.valid() {
+		return o
+	}
+	return transformObject(o.expandAll(), fn)
+}
+
+// ToWebMercator returns a copy of the object with the longitude and latitude
+// projected to Web Mercator (EPSG:3857) meters. Latitudes are limited to the
+// range of the projection, about ±85.05.
+func (o Object) ToWebMercator() Object {
+	return o.Transform(func(p Position) Position {
+		lat := math.Max(-maxLatitude, math.Min(maxLatitude, p.Y))
+		return Position{
+			X: p.X * math.Pi / 180 * webMercatorRadius,
+			Y: math.Log(math.Tan(math.Pi/4+lat*math.Pi/360)) * webMercatorRadius,
+			Z: p.Z,
+		}
+	})
+}
+
+// FromWebMercator returns a copy of the object with the Web Mercator
+// (EPSG:3857) meters converted to longitude and latitude.
+func (o Object) FromWebMercator() Object {
+	return o.Transform(func(p Position) Position {
+		return Position{
+			X: p.X / webMercatorRadius * 180 / math.Pi,
+			Y: (2*math.Atan(math.Exp(p.Y/webMercatorRadius)) - math.Pi/2) * 180 / math.Pi,
+			Z: p.Z,
+		}
+	})
+}
+
+// Translate returns a copy of the object that is moved by dx and dy.
+func (o Object) Translate(dx, dy float64) Object {
+	return o.Transform(func(p Position) Position {
+		return Position{X: p.X + dx, Y: p.Y + dy, Z: p.Z}
+	})
+}
+
+// Scale returns a copy of the object that is scaled by sx and sy from the
+// origin.
+func (o Object) Scale(sx, sy float64, origin Position) Object {
+	return o.Transform(func(p Position) Position {
+		return Position{
+			X: origin.X + (p.X-origin.X)*sx,
+			Y: origin.Y + (p.Y-origin.Y)*sy,
+			Z: p.Z,
+		}
+	})
+}
+
+// Rotate returns a copy of the object that is rotated counterclockwise by
+// the angle, in degrees, around the origin.
+func (o Object) Rotate(angle float64, origin Position) Object {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	return o.Transform(func(p Position) Position {
+		x, y := p.X-origin.X, p.Y-origin.Y
+		return Position{
+			X: origin.X + x*cos - y*sin,
+			Y: origin.Y + x*sin + y*cos,
+			Z: p.Z,
+		}
+	})
+}
+
+// transformObject transforms a valid object that has no compacted
+// coordinates.
+func transformObject(o Object, fn func(p Position) Position) Object {
+	tail := o.data[len(o.data)-1]
+	if tail>>3&1 == 0 {
+		var t Object
+		switch tail & 15 {
+		case 1, 3:
+			p := fn(o.Position())
+			if tail>>1&1 == 1 {
+				t = Make3DPoint(p.X, p.Y, p.Z)
+			} else {
+				t = Make2DPoint(p.X, p.Y)
+			}
+		case 5:
+			t = makeLevel2(Polygon, [][]Position{o.polySimplePairsFor2DRect()}, 2)
+			t = transformObject(t, fn)
+		default:
+			var polys [][][]Position
+			for _, face := range o.polySimplePairsFor3DRect() {
+				polys = append(polys, [][]Position{face})
+			}
+			t = transformObject(makeLevel3(MultiPolygon, polys, 3), fn)
+		}
+		if exdata := o.ExData(); len(exdata) > 0 {
+			t = t.SetExData(exdata)
+		}
+		return t
+	}
+	g := o.geometryData()
+	min, max := baseMin, baseMax
+	var count int
+	var t Object
+	if geomDepth(g.Type) == -1 {
+		t, _ = o.mapChildren(func(child Object) (Object, bool) {
+			child = transformObject(child, fn)
+			cmin, cmax := child.Rect(nil)
+			for i := 0; i < child.Dims(); i++ {
+				min[i] = math.Min(min[i], cmin[i])
+				max[i] = math.Max(max[i], cmax[i])
+			}
+			count++
+			return child, true
+		})
+	} else {
+		w := transformWriter{fn: fn, dims: g.Dims, min: baseMin, max: baseMax}
+		c := o.parseComponents()
+		data := append([]byte{}, c.data[:len(c.data)-len(g.Data)]...)
+		data, _ = w.transform(data, g.Data, geomDepth(g.Type))
+		c.data = data
+		t = c.reconstructObject()
+		min, max, count = w.min, w.max, w.count
+	}
+	if count > 0 {
+		c := t.parseComponents()
+		c.bbox = make([]byte, len(c.bbox))
+		putBBox(c.bbox, g.Dims, min, max)
+		t = c.reconstructObject()
+	}
+	return t
+}
+
+type transformWriter struct {
+	fn       func(p Position) Position
+	dims     int
+	count    int        // positions written
+	min, max [3]float64 // bounds of the positions written
+}
+
+// transform appends the transformed coordinates at the depth, and returns
+// the remaining data.
+func (w *transformWriter) transform(out, data []byte, depth int) ([]byte, []byte) {
+	if depth == 0 {
+		var p Position
+		p, data = readPosition(data, w.dims)
+		p = w.fn(p)
+		vals := [3]float64{p.X, p.Y, p.Z}
+		for i := 0; i < w.dims; i++ {
+			out = appendFloat64(out, vals[i])
+			w.min[i] = math.Min(w.min[i], vals[i])
+			w.max[i] = math.Max(w.max[i], vals[i])
+		}
+		w.count++
+		return out, data
+	}
+	n, data := readUint32(data)
+	out = append(out, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(out[len(out)-4:], uint32(n))
+	for i := 0; i < n; i++ {
+		out, data = w.transform(out, data, depth-1)
+	}
+	return out, data
+}
@@ -0,0 +1,88 @@
+package geobin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	var tests = []struct{ in, out string }{
+		{`{"type":"Point","coordinates":[1,2]}`, `{"type":"Point","coordinates":[11,22]}`},
+		{`{"type":"Point","coordinates":[1,2,3]}`, `{"type":"Point","coordinates":[11,22,3]}`},
+		{`{"type":"Point","coordinates":[1,2],"bbox":[1,2,1,2]}`, `{"type":"Point","bbox":[11,22,11,22],"coordinates":[11,22]}`},
+		{`{"type":"LineString","coordinates":[[1,2],[3,4]]}`, `{"type":"LineString","coordinates":[[11,22],[13,24]]}`},
+		{`{"type":"LineString","coordinates":[[1,2,5],[3,4,6]],"bbox":[0,0,0,9,9,9]}`,
+			`{"type":"LineString","bbox":[11,22,5,13,24,6],"coordinates":[[11,22,5],[13,24,6]]}`},
+		{`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`,
+			`{"type":"Polygon","coordinates":[[[10,20],[11,20],[11,21],[10,20]]]}`},
+		{`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],[[[2,2],[3,2],[3,3],[2,2]]]]}`,
+			`{"type":"MultiPolygon","coordinates":[[[[10,20],[11,20],[11,21],[10,20]]],[[[12,22],[13,22],[13,23],[12,22]]]]}`},
+		{`{"type":"Feature","id":1,"properties":{"a":"b"},"geometry":{"type":"Point","coordinates":[1,2]},"bbox":[0,0,5,5]}`,
+			`{"type":"Feature","bbox":[11,22,11,22],"geometry":{"type":"Point","coordinates":[11,22]},"id":1,"properties":{"a":"b"}}`},
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[3,4],[5,6]]}]}`,
+			`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[11,22]},{"type":"LineString","coordinates":[[13,24],[15,26]]}]}`},
+		{`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":null}]}`,
+			`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[11,22]},"properties":null}]}`},
+	}
+	for _, tt := range tests {
+		o := ParseJSON(tt.in).Translate(10, 20)
+		assert.Equal(t, tt.out, o.JSON(), tt.in)
+		assert.NoError(t, Validate(o.Binary()), tt.in)
+	}
+
+	// the bbox of a collection is recalculated
+	o := ParseJSON(tests[8].in).Translate(10, 20)
+	assert.Equal(t, BBox{Min: P(11, 22), Max: P(15, 26)}, o.BBox())
+
+	// simple rects become polygons
+	o = Make2DRect(0, 0, 1, 2).Translate(1, 1)
+	assert.Equal(t, `{"type":"Polygon","coordinates":[[[1,1],[2,1],[2,3],[1,3],[1,1]]]}`, o.JSON())
+	o = Make3DRect(0, 0, 0, 1, 1, 1).Translate(1, 1)
+	assert.Equal(t, MultiPolygon, o.GeometryType())
+	assert.Equal(t, BBox{Min: P3(1, 1, 0), Max: P3(2, 2, 1)}, o.BBox())
+
+	// exdata is kept
+	o = Make2DPoint(1, 2).SetExData([]byte("hello")).Translate(1, 1)
+	assert.Equal(t, `{"type":"Point","coordinates":[2,3]}`, o.JSON())
+	assert.Equal(t, "hello", string(o.ExData()))
+	o = ParseJSON(tests[3].in).SetExData([]byte("hello")).Translate(1, 1)
+	assert.Equal(t, "hello", string(o.ExData()))
+
+	// compacted coordinates are expanded
+	o = ParseJSON(tests[3].in).Compact(0.001).Translate(10, 20)
+	assert.Equal(t, tests[3].out, o.JSON())
+	assert.False(t, o.IsCompact())
+
+	// not a geometry
+	assert.Equal(t, "hello", MakeString("hello").Translate(1, 1).String())
+}
+
+func TestAffine(t *testing.T) {
+	o := ParseJSON(`{"type":"LineString","coordinates":[[2,1,5],[1,1,5]]}`)
+	r := collectPositions(o.Rotate(90, P(1, 1)))
+	assertNearPosition(t, P3(1, 2, 5), r[0])
+	assertNearPosition(t, P3(1, 1, 5), r[1])
+	r = collectPositions(o.Scale(2, 3, P(1, 0)))
+	assertNearPosition(t, P3(3, 3, 5), r[0])
+	assertNearPosition(t, P3(1, 3, 5), r[1])
+}
+
+func TestWebMercator(t *testing.T) {
+	o := ParseJSON(`{"type":"LineString","coordinates":[[180,0],[-180,85.0511287798066],[10,20]]}`)
+	m := collectPositions(o.ToWebMercator())
+	assert.InDelta(t, 20037508.342789244, m[0].X, 1e-6)
+	assert.InDelta(t, 0, m[0].Y, 1e-6)
+	assert.InDelta(t, -20037508.342789244, m[1].X, 1e-6)
+	assert.InDelta(t, 20037508.342789244, m[1].Y, 1e-3)
+	assert.InDelta(t, 1113194.9079327357, m[2].X, 1e-6)
+	assert.InDelta(t, 2273030.926987689, m[2].Y, 1e-6)
+	back := collectPositions(o.ToWebMercator().FromWebMercator())
+	for i, p := range collectPositions(o) {
+		assert.InDelta(t, p.X, back[i].X, 1e-9)
+		assert.InDelta(t, p.Y, back[i].Y, 1e-9)
+	}
+	// beyond the poles
+	m = collectPositions(ParseJSON(`{"type":"Point","coordinates":[0,90]}`).ToWebMercator())
+	assert.InDelta(t, 20037508.342789244, m[0].Y, 1e-3)
+}
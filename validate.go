@@ -0,0 +1,270 @@
+package geobin
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+)
+
+// Errors wrapped by a *ValidationError. Use errors.Is to test for them.
+var (
+	ErrTruncated   = errors.New("truncated data")
+	ErrInvalidTail = errors.New("invalid tail")
+	ErrInvalidHead = errors.New("invalid head")
+	ErrInvalidSize = errors.New("invalid size")
+)
+
+// ValidationError is returned by Validate when the data is not a well formed
+// geobin object.
+type ValidationError struct {
+	// Offset is the byte offset into the data where the problem was found.
+	Offset int
+	// Err is one of ErrTruncated, ErrInvalidTail, ErrInvalidHead,
+	// or ErrInvalidSize.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error() + " at offset " + strconv.Itoa(e.Offset)
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks that data is a well formed geobin object. The entire
+// layout is walked, including the members and the geometries of nested
+// collections. The first problem found is returned as a *ValidationError.
+// An empty slice is valid and represents an empty object.
+func Validate(data []byte) error {
+	return validateObject(data, 0)
+}
+
+// WrapBinaryChecked creates an object by wrapping data, but unlike WrapBinary
+// the data is validated first.
+func WrapBinaryChecked(data []byte) (Object, error) {
+	if err := Validate(data); err != nil {
+		return Object{}, err
+	}
+	return Object{data}, nil
+}
+
+// valid returns true if the object is well formed.
+func (o Object) valid() bool {
+	return validateObject(o.data, 0) == nil
+}
+
+func invalidAt(offset int, err error) error {
+	return &ValidationError{Offset: offset, Err: err}
+}
+
+// bboxSizeForTail returns the size of the [BBOX] component for a geometry.
+func bboxSizeForTail(tail byte) int {
+	if tail>>1&1 == 1 {
+		if tail>>2&1 == 1 {
+			return 48 // 3D rect
+		}
+		return 24 // 3D point
+	}
+	if tail>>2&1 == 1 {
+		return 32 // 2D rect
+	}
+	return 16 // 2D point
+}
+
+// validateObject validates an [OBJECT]. The base is the offset of the
+// object in the outermost data and is used for error reporting.
+func validateObject(data []byte, base int) error {
+	if len(data) == 0 {
+		return nil
+	}
+	end := len(data) - 1
+	tail := data[end]
+	if tail>>5 != 0 {
+		// unused bits
+		return invalidAt(base+end, ErrInvalidTail)
+	}
+	if tail&1 == 0 && tail&14 != 0 {
+		// strings cannot be 3D, rects, or complex
+		return invalidAt(base+end, ErrInvalidTail)
+	}
+	if tail>>4&1 == 1 {
+		// has exdata
+		if end < 4 {
+			return invalidAt(base, ErrTruncated)
+		}
+		end -= 4
+		exsz := int(binary.LittleEndian.Uint32(data[end:]))
+		if exsz > end {
+			return invalidAt(base+end, ErrInvalidSize)
+		}
+		end -= exsz
+	}
+	if tail&1 == 0 {
+		// string, any bytes are allowed
+		return nil
+	}
+	bboxSize := bboxSizeForTail(tail)
+	if end < bboxSize {
+		return invalidAt(base+end, ErrTruncated)
+	}
+	if tail>>3&1 == 0 {
+		// simple, the bbox is the entire geometry
+		if end != bboxSize {
+			return invalidAt(base+bboxSize, ErrInvalidSize)
+		}
+		return nil
+	}
+	dims := 2
+	if tail>>1&1 == 1 {
+		dims = 3
+	}
+	return validateComplex(data[bboxSize:end], base+bboxSize, dims)
+}
+
+// validateComplex validates the [HEAD][MEMBERSIZE][MEMBERS][GEOM] data.
+func validateComplex(data []byte, base int, dims int) error {
+	if len(data) == 0 {
+		return invalidAt(base, ErrTruncated)
+	}
+	head := data[0]
+	typ := GeometryType(head >> 4)
+	if head&8 != 0 || typ == Unknown || typ > FeatureCollection {
+		return invalidAt(base, ErrInvalidHead)
+	}
+	compact := head>>2&1 == 1
+	if compact && geomDepth(typ) == -1 {
+		// only geometries with coordinates are compacted
+		return invalidAt(base, ErrInvalidHead)
+	}
+	off := 1
+	if head&1 == 1 {
+		// has members
+		if len(data)-off < 4 {
+			return invalidAt(base+off, ErrTruncated)
+		}
+		sz := int(binary.LittleEndian.Uint32(data[off:]))
+		off += 4
+		if sz > len(data)-off {
+			return invalidAt(base+off-4, ErrInvalidSize)
+		}
+		off += sz
+	}
+	var n int
+	var err error
+	if compact {
+		n, err = validateCompactGeom(data[off:], base+off, typ, dims)
+	} else {
+		n, err = validateGeom(data[off:], base+off, typ, dims)
+	}
+	if err != nil {
+		return err
+	}
+	if off+n != len(data) {
+		return invalidAt(base+off+n, ErrInvalidSize)
+	}
+	return nil
+}
+
+// validateGeom validates the [GEOM] data and returns the number of bytes
+// that it occupies.
+func validateGeom(data []byte, base int, typ GeometryType, dims int) (int, error) {
+	switch typ {
+	default:
+		return 0, invalidAt(base, ErrInvalidHead)
+	case Point:
+		if len(data) < dims*8 {
+			return 0, invalidAt(base, ErrTruncated)
+		}
+		return dims * 8, nil
+	case MultiPoint, LineString:
+		return validateCoords(data, base, 1, dims)
+	case MultiLineString, Polygon:
+		return validateCoords(data, base, 2, dims)
+	case MultiPolygon:
+		return validateCoords(data, base, 3, dims)
+	case Feature:
+		return validateChild(data, base)
+	case GeometryCollection, FeatureCollection:
+		if len(data) < 4 {
+			return 0, invalidAt(base, ErrTruncated)
+		}
+		n := int(binary.LittleEndian.Uint32(data))
+		off := 4
+		for i := 0; i < n; i++ {
+			sz, err := validateChild(data[off:], base+off)
+			if err != nil {
+				return 0, err
+			}
+			off += sz
+		}
+		return off, nil
+	}
+}
+
+// validateCoords validates a [UINT32][...] coordinates block and returns
+// the number of bytes that it occupies.
+func validateCoords(data []byte, base int, depth, dims int) (int, error) {
+	if len(data) < 4 {
+		return 0, invalidAt(base, ErrTruncated)
+	}
+	n := int(binary.LittleEndian.Uint32(data))
+	off := 4
+	if depth == 1 {
+		if n > (len(data)-off)/(dims*8) {
+			return 0, invalidAt(base, ErrInvalidSize)
+		}
+		return off + n*dims*8, nil
+	}
+	for i := 0; i < n; i++ {
+		sz, err := validateCoords(data[off:], base+off, depth-1, dims)
+		if err != nil {
+			return 0, err
+		}
+		off += sz
+	}
+	return off, nil
+}
+
+// validateCompactGeom validates compacted [GEOM] data and returns the number
+// of bytes that it occupies.
+func validateCompactGeom(data []byte, base int, typ GeometryType, dims int) (int, error) {
+	if len(data) < 8 {
+		return 0, invalidAt(base, ErrTruncated)
+	}
+	scale, _ := readFloat64(data)
+	if !(scale > 0) || math.IsInf(scale, 0) {
+		return 0, invalidAt(base, ErrInvalidSize)
+	}
+	r := compactReader{data: data[8:], scale: scale, dims: dims}
+	if !r.skip(geomDepth(typ)) {
+		return 0, invalidAt(base+len(data)-len(r.data), ErrInvalidSize)
+	}
+	return len(data) - len(r.data), nil
+}
+
+// validateChild validates a [UINT32][OBJECT] block and returns the number
+// of bytes that it occupies. The object must be a geometry.
+func validateChild(data []byte, base int) (int, error) {
+	if len(data) < 4 {
+		return 0, invalidAt(base, ErrTruncated)
+	}
+	sz := int(binary.LittleEndian.Uint32(data))
+	if sz > len(data)-4 {
+		return 0, invalidAt(base, ErrInvalidSize)
+	}
+	if sz == 0 {
+		return 0, invalidAt(base+4, ErrTruncated)
+	}
+	child := data[4 : 4+sz]
+	if child[sz-1]&1 == 0 {
+		// collections and features may only contain geometries
+		return 0, invalidAt(base+4+sz-1, ErrInvalidTail)
+	}
+	if err := validateObject(child, base+4); err != nil {
+		return 0, err
+	}
+	return 4 + sz, nil
+}
@@ -0,0 +1,135 @@
+package geobin
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testValidateJSON = []string{
+	`{"type":"Point","coordinates":[10,11]}`,
+	`{"type":"Point","coordinates":[10,11,12],"bbox":[0,0,0,20,20,20]}`,
+	`{"type":"MultiPoint","coordinates":[[100.1,5.1],[101.1,51.1]]}`,
+	`{"type":"LineString","coordinates":[[100.1,5.1,15.5],[101.1,51.1,20]]}`,
+	`{"type":"MultiLineString","coordinates":[[[1,2],[3,4]],[[5,6],[7,8]]],"bbox":[1,2,7,8]}`,
+	testPolyHoles,
+	`{"type":"MultiPolygon","coordinates":[[[[0,0],[0,1],[1,1],[0,0]]],[[[5,5],[5,6],[6,6],[5,5]]]]}`,
+	`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[1,2],[3,4]]}]}`,
+	`{"type":"Feature","id":"abc","properties":{"a":1},"geometry":{"type":"Point","coordinates":[1,2]}}`,
+	`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}},{"type":"Feature","properties":{"b":2},"geometry":{"type":"Polygon","coordinates":[[[0,0],[0,1],[1,1],[0,0]]]}}]}`,
+}
+
+func testValidateObjects() []Object {
+	objs := []Object{
+		{},
+		MakeString("hello"),
+		MakeString("hello").SetExData([]byte("extra")),
+		Make2DPoint(1, 2),
+		Make3DPoint(1, 2, 3),
+		Make2DRect(1, 2, 3, 4),
+		Make3DRect(1, 2, 3, 4, 5, 6).SetExData([]byte("extra")),
+	}
+	for _, js := range testValidateJSON {
+		o := ParseJSON(js)
+		objs = append(objs, o, o.SetExData([]byte("extra")),
+			o.Compact(1e-6), o.SetExData([]byte("extra")).Compact(1e-6))
+	}
+	return objs
+}
+
+// touchAccessors calls every accessor that reads the raw data.
+func touchAccessors(o Object) {
+	o.JSON()
+	o.StringBytes()
+	o.Members()
+	o.ExData()
+	o.SetExData([]byte("x"))
+	o.GeometryType()
+	o.PositionCount()
+	o.Dims()
+	o.Rect(nil)
+	o.Area()
+	o.Perimeter()
+	o.Centroid()
+	o.PointOnSurface()
+	o.bridge()
+}
+
+func TestValidate(t *testing.T) {
+	for _, o := range testValidateObjects() {
+		assert.NoError(t, Validate(o.Binary()))
+		o2, err := WrapBinaryChecked(o.Binary())
+		assert.NoError(t, err)
+		assert.Equal(t, o.JSON(), o2.JSON())
+	}
+}
+
+func TestValidateTruncated(t *testing.T) {
+	for _, o := range testValidateObjects() {
+		data := o.Binary()
+		if len(data) == 0 || !o.IsGeometry() {
+			continue
+		}
+		for i := 1; i < len(data); i++ {
+			// chop off the head, keeping the tail
+			bad := append(append([]byte{}, data[:len(data)-1-i]...), data[len(data)-1])
+			err := Validate(bad)
+			if !assert.Error(t, err) {
+				continue
+			}
+			var verr *ValidationError
+			assert.True(t, errors.As(err, &verr))
+			assert.True(t, verr.Offset >= 0 && verr.Offset < len(data))
+			_, err = WrapBinaryChecked(bad)
+			assert.Error(t, err)
+			touchAccessors(WrapBinary(bad))
+		}
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	o := ParseJSON(`{"type":"LineString","coordinates":[[1,2],[3,4]]}`)
+	data := append([]byte{}, o.Binary()...)
+	// corrupt the coordinate count
+	data[33] = 0xFF
+	err := Validate(data)
+	assert.True(t, errors.Is(err, ErrInvalidSize))
+	assert.Equal(t, 33, err.(*ValidationError).Offset)
+	assert.Equal(t, `{"type":"Unknown"}`, WrapBinary(data).JSON())
+
+	// corrupt the head type
+	data = append([]byte{}, o.Binary()...)
+	data[32] = 0xF0
+	err = Validate(data)
+	assert.True(t, errors.Is(err, ErrInvalidHead))
+	assert.Equal(t, 32, err.(*ValidationError).Offset)
+
+	// unused tail bits
+	data = append([]byte{}, o.Binary()...)
+	data[len(data)-1] |= 0x80
+	assert.True(t, errors.Is(Validate(data), ErrInvalidTail))
+
+	// trailing garbage on a simple point
+	data = append(Make2DPoint(1, 2).Binary()[:16:16], 0, 1)
+	assert.True(t, errors.Is(Validate(data), ErrInvalidSize))
+}
+
+func TestValidateRandom(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	objs := testValidateObjects()
+	for i := 0; i < 20000; i++ {
+		o := objs[rand.Int()%len(objs)]
+		data := append([]byte{}, o.Binary()...)
+		if len(data) == 0 {
+			continue
+		}
+		for j := 0; j < 1+rand.Int()%3; j++ {
+			data[rand.Int()%len(data)] = byte(rand.Int())
+		}
+		Validate(data)
+		touchAccessors(WrapBinary(data))
+	}
+}
@@ -0,0 +1,455 @@
+package geobin
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	errInvalidWKB    = errors.New("invalid wkb")
+	errEmptyWKBPoint = errors.New("invalid wkb, empty points are not supported")
+)
+
+// WKB geometry types
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+)
+
+// EWKB type flags
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// ParseWKB parses Well-Known Binary and returns a geobin object. Both ISO WKB
+// and the PostGIS EWKB flavor are supported, including the Z variants. M
+// values are dropped and the EWKB SRID is ignored. Empty points cannot be
+// represented and return an error.
+func ParseWKB(data []byte) (Object, error) {
+	o, i, err := parseWKB(data, 0)
+	if err != nil {
+		return Object{}, err
+	}
+	if i != len(data) {
+		return Object{}, errInvalidWKB
+	}
+	return o, nil
+}
+
+// wkbHeader is the byte order and type of a wkb geometry.
+type wkbHeader struct {
+	order  binary.ByteOrder
+	typ    uint32
+	dims   int // 2 or 3
+	stride int // number of values per position, includes M
+}
+
+func readWKBHeader(data []byte, i int) (h wkbHeader, j int, err error) {
+	if len(data)-i < 5 {
+		return h, 0, errInvalidWKB
+	}
+	switch data[i] {
+	case 0:
+		h.order = binary.BigEndian
+	case 1:
+		h.order = binary.LittleEndian
+	default:
+		return h, 0, errInvalidWKB
+	}
+	typ := h.order.Uint32(data[i+1:])
+	i += 5
+	if typ&ewkbSRID != 0 {
+		if len(data)-i < 4 {
+			return h, 0, errInvalidWKB
+		}
+		i += 4
+	}
+	z, m := typ&ewkbZ != 0, typ&ewkbM != 0
+	typ &^= ewkbZ | ewkbM | ewkbSRID
+	switch typ / 1000 {
+	case 1:
+		z = true
+	case 2:
+		m = true
+	case 3:
+		z, m = true, true
+	}
+	h.typ = typ % 1000
+	if h.typ < wkbPoint || h.typ > wkbGeometryCollection || typ >= 4000 {
+		return h, 0, errInvalidWKB
+	}
+	h.dims, h.stride = 2, 2
+	if z {
+		h.dims++
+		h.stride++
+	}
+	if m {
+		h.stride++
+	}
+	return h, i, nil
+}
+
+func readWKBCount(data []byte, i int, h wkbHeader) (n, j int, err error) {
+	if len(data)-i < 4 {
+		return 0, 0, errInvalidWKB
+	}
+	return int(h.order.Uint32(data[i:])), i + 4, nil
+}
+
+// parseWKB parses the geometry at i and returns the object and the end of
+// the geometry.
+func parseWKB(data []byte, i int) (Object, int, error) {
+	h, i, err := readWKBHeader(data, i)
+	if err != nil {
+		return Object{}, 0, err
+	}
+	switch h.typ {
+	case wkbPoint:
+		if len(data)-i < h.stride*8 {
+			return Object{}, 0, errInvalidWKB
+		}
+		x := math.Float64frombits(h.order.Uint64(data[i:]))
+		y := math.Float64frombits(h.order.Uint64(data[i+8:]))
+		if math.IsNaN(x) && math.IsNaN(y) {
+			return Object{}, 0, errEmptyWKBPoint
+		}
+		if h.dims == 3 {
+			z := math.Float64frombits(h.order.Uint64(data[i+16:]))
+			return Make3DPoint(x, y, z), i + h.stride*8, nil
+		}
+		return Make2DPoint(x, y), i + h.stride*8, nil
+	case wkbGeometryCollection:
+		n, i, err := readWKBCount(data, i, h)
+		if err != nil {
+			return Object{}, 0, err
+		}
+		var geoms []Object
+		for j := 0; j < n; j++ {
+			var g Object
+			g, i, err = parseWKB(data, i)
+			if err != nil {
+				return Object{}, 0, err
+			}
+			geoms = append(geoms, g)
+		}
+		return collectionObject(GeometryCollection, gjson.Result{}, geoms), i, nil
+	}
+	// The first pass checks the geometry and finds the dimensions and
+	// bounds. The second pass writes the packed coordinates.
+	p := wkbParser{data: data, min: baseMin, max: baseMax}
+	end, err := p.geom(i, h)
+	if err != nil {
+		return Object{}, 0, err
+	}
+	if p.dims < 2 {
+		p.dims = 2
+	}
+	tail, raw, _ := tailFromBBoxJSONOrMakeIfNeeded(gjson.Result{}, p.min, p.max, p.dims)
+	typ := [...]GeometryType{
+		wkbLineString:      LineString,
+		wkbPolygon:         Polygon,
+		wkbMultiPoint:      MultiPoint,
+		wkbMultiLineString: MultiLineString,
+		wkbMultiPolygon:    MultiPolygon,
+	}[h.typ]
+	p.write, p.out = true, append(raw, byte(typ)<<4)
+	p.geom(i, h)
+	return Object{append(p.out, tail)}, end, nil
+}
+
+type wkbParser struct {
+	data     []byte
+	write    bool   // write pass
+	out      []byte // write pass output
+	dims     int
+	min, max [3]float64
+}
+
+// count reads a count and writes it to the output.
+func (p *wkbParser) count(i int, h wkbHeader) (int, int, error) {
+	n, i, err := readWKBCount(p.data, i, h)
+	if err != nil {
+		return 0, 0, err
+	}
+	if p.write {
+		p.out = append(p.out, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(p.out[len(p.out)-4:], uint32(n))
+	}
+	return n, i, nil
+}
+
+// positions reads n positions.
+func (p *wkbParser) positions(i int, h wkbHeader, n int) (int, error) {
+	if n < 0 || (len(p.data)-i)/(h.stride*8) < n {
+		return 0, errInvalidWKB
+	}
+	for j := 0; j < n; j++ {
+		var vals [3]float64
+		for k := 0; k < h.dims; k++ {
+			vals[k] = math.Float64frombits(h.order.Uint64(p.data[i+k*8:]))
+		}
+		i += h.stride * 8
+		if p.write {
+			for k := 0; k < p.dims; k++ {
+				p.out = append(p.out, 0, 0, 0, 0, 0, 0, 0, 0)
+				binary.LittleEndian.PutUint64(p.out[len(p.out)-8:], math.Float64bits(vals[k]))
+			}
+			continue
+		}
+		for k := 0; k < h.dims; k++ {
+			if vals[k] < p.min[k] {
+				p.min[k] = vals[k]
+			}
+			if vals[k] > p.max[k] {
+				p.max[k] = vals[k]
+			}
+		}
+	}
+	return i, nil
+}
+
+// series reads a counted series of positions.
+func (p *wkbParser) series(i int, h wkbHeader) (int, error) {
+	n, i, err := p.count(i, h)
+	if err != nil {
+		return 0, err
+	}
+	return p.positions(i, h, n)
+}
+
+// geom reads the body of a geometry that follows the header.
+func (p *wkbParser) geom(i int, h wkbHeader) (int, error) {
+	if h.dims > p.dims {
+		p.dims = h.dims
+	}
+	switch h.typ {
+	case wkbPoint:
+		return p.positions(i, h, 1)
+	case wkbLineString:
+		return p.series(i, h)
+	case wkbPolygon:
+		n, i, err := p.count(i, h)
+		for j := 0; j < n && err == nil; j++ {
+			i, err = p.series(i, h)
+		}
+		return i, err
+	case wkbMultiPoint, wkbMultiLineString, wkbMultiPolygon:
+		n, i, err := p.count(i, h)
+		for j := 0; j < n && err == nil; j++ {
+			var ch wkbHeader
+			ch, i, err = readWKBHeader(p.data, i)
+			if err != nil {
+				break
+			}
+			if ch.typ != h.typ-3 {
+				// not a Point, LineString, or Polygon
+				return 0, errInvalidWKB
+			}
+			if ch.typ == wkbPoint && len(p.data)-i >= 16 &&
+				math.IsNaN(math.Float64frombits(ch.order.Uint64(p.data[i:]))) &&
+				math.IsNaN(math.Float64frombits(ch.order.Uint64(p.data[i+8:]))) {
+				return 0, errEmptyWKBPoint
+			}
+			i, err = p.geom(i, ch)
+		}
+		return i, err
+	}
+	return 0, errInvalidWKB
+}
+
+// WKB returns the Well-Known Binary representation of the object in little
+// endian byte order.
+func (o Object) WKB() []byte {
+	return o.AppendWKB(nil, binary.LittleEndian)
+}
+
+// AppendWKB appends the ISO Well-Known Binary representation of the object to
+// the provided input bytes and returns the modified slice. Features are
+// written as their geometry and FeatureCollections as a GeometryCollection.
+// Nothing is appended for objects that are not valid geometries.
+func (o Object) AppendWKB(b []byte, byteOrder binary.ByteOrder) []byte {
+	v, ok := o.view()
+	if !ok {
+		return b
+	}
+	w := newWKBWriter(byteOrder, false, 0)
+	return w.append(b, v)
+}
+
+// AppendEWKB appends the PostGIS Extended Well-Known Binary representation of
+// the object to the provided input bytes and returns the modified slice. The
+// srid is included when it's not zero.
+func (o Object) AppendEWKB(b []byte, byteOrder binary.ByteOrder, srid uint32) []byte {
+	v, ok := o.view()
+	if !ok {
+		return b
+	}
+	w := newWKBWriter(byteOrder, true, srid)
+	return w.append(b, v)
+}
+
+type wkbWriter struct {
+	order binary.ByteOrder
+	flag  byte // 0 for big endian, 1 for little endian
+	ewkb  bool
+	srid  uint32 // written to the first header only
+}
+
+func newWKBWriter(order binary.ByteOrder, ewkb bool, srid uint32) *wkbWriter {
+	var buf [2]byte
+	order.PutUint16(buf[:], 1)
+	return &wkbWriter{order: order, flag: buf[0], ewkb: ewkb, srid: srid}
+}
+
+func (w *wkbWriter) uint32(b []byte, n uint32) []byte {
+	b = append(b, 0, 0, 0, 0)
+	w.order.PutUint32(b[len(b)-4:], n)
+	return b
+}
+
+func (w *wkbWriter) float64(b []byte, f float64) []byte {
+	b = append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+	w.order.PutUint64(b[len(b)-8:], math.Float64bits(f))
+	return b
+}
+
+func (w *wkbWriter) header(b []byte, typ uint32, dims int) []byte {
+	b = append(b, w.flag)
+	if !w.ewkb {
+		if dims == 3 {
+			typ += 1000
+		}
+		return w.uint32(b, typ)
+	}
+	if dims == 3 {
+		typ |= ewkbZ
+	}
+	if w.srid == 0 {
+		return w.uint32(b, typ)
+	}
+	b = w.uint32(b, typ|ewkbSRID)
+	b = w.uint32(b, w.srid)
+	w.srid = 0
+	return b
+}
+
+func (w *wkbWriter) position(b []byte, p Position, dims int) []byte {
+	b = w.float64(b, p.X)
+	b = w.float64(b, p.Y)
+	if dims == 3 {
+		b = w.float64(b, p.Z)
+	}
+	return b
+}
+
+func (w *wkbWriter) series(b []byte, s series) []byte {
+	b = w.uint32(b, uint32(s.n))
+	for i := 0; i < s.n; i++ {
+		b = w.position(b, s.at(i), s.dims)
+	}
+	return b
+}
+
+func (w *wkbWriter) seriesList(b []byte, l seriesList) []byte {
+	b = w.uint32(b, uint32(l.n))
+	l.forEach(func(s series) bool {
+		b = w.series(b, s)
+		return true
+	})
+	return b
+}
+
+func (w *wkbWriter) pairs(b []byte, pairs [][]float64) []byte {
+	b = w.uint32(b, uint32(len(pairs)))
+	for _, pair := range pairs {
+		for _, val := range pair {
+			b = w.float64(b, val)
+		}
+	}
+	return b
+}
+
+func (w *wkbWriter) append(b []byte, v view) []byte {
+	if tail := v.obj.data[len(v.obj.data)-1]; tail>>3&1 == 0 && tail>>2&1 == 1 {
+		// simple rects are written the same as the geojson polygons
+		if v.dims == 2 {
+			b = w.header(b, wkbPolygon, 2)
+			b = w.uint32(b, 1)
+			return w.pairs(b, v.obj.simplePairsFor2DRect())
+		}
+		faces := v.obj.simplePairsFor3DRect()
+		b = w.header(b, wkbMultiPolygon, 3)
+		b = w.uint32(b, uint32(len(faces)))
+		for _, pairs := range faces {
+			b = w.header(b, wkbPolygon, 3)
+			b = w.uint32(b, 1)
+			b = w.pairs(b, pairs)
+		}
+		return b
+	}
+	switch v.typ {
+	case Point:
+		b = w.header(b, wkbPoint, v.dims)
+		return w.position(b, v.point, v.dims)
+	case LineString:
+		b = w.header(b, wkbLineString, v.dims)
+		s, _ := readSeries(v.geom, v.dims)
+		return w.series(b, s)
+	case MultiPoint:
+		b = w.header(b, wkbMultiPoint, v.dims)
+		s, _ := readSeries(v.geom, v.dims)
+		b = w.uint32(b, uint32(s.n))
+		for i := 0; i < s.n; i++ {
+			b = w.header(b, wkbPoint, v.dims)
+			b = w.position(b, s.at(i), v.dims)
+		}
+		return b
+	case Polygon:
+		b = w.header(b, wkbPolygon, v.dims)
+		l, _ := readSeriesList(v.geom, v.dims)
+		return w.seriesList(b, l)
+	case MultiLineString:
+		b = w.header(b, wkbMultiLineString, v.dims)
+		l, _ := readSeriesList(v.geom, v.dims)
+		b = w.uint32(b, uint32(l.n))
+		l.forEach(func(s series) bool {
+			b = w.header(b, wkbLineString, v.dims)
+			b = w.series(b, s)
+			return true
+		})
+		return b
+	case MultiPolygon:
+		b = w.header(b, wkbMultiPolygon, v.dims)
+		n, _ := readUint32(v.geom)
+		b = w.uint32(b, uint32(n))
+		forEachPolygon(v.geom, v.dims, func(rings seriesList) bool {
+			b = w.header(b, wkbPolygon, v.dims)
+			b = w.seriesList(b, rings)
+			return true
+		})
+		return b
+	case Feature:
+		return w.append(b, v.child())
+	case GeometryCollection, FeatureCollection:
+		b = w.header(b, wkbGeometryCollection, v.dims)
+		n, data := v.children()
+		b = w.uint32(b, uint32(n))
+		for i := 0; i < n; i++ {
+			var c view
+			c, data = nextChild(data)
+			b = w.append(b, c)
+		}
+		return b
+	}
+	return b
+}
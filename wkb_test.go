@@ -0,0 +1,122 @@
+package geobin
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWKB(t *testing.T) {
+	for _, js := range testValidateJSON {
+		expect := ParseJSON(js)
+		if expect.GeometryType() == Feature || expect.GeometryType() == FeatureCollection {
+			continue
+		}
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			o, err := ParseWKB(expect.AppendWKB(nil, order))
+			if !assert.NoError(t, err, js) {
+				continue
+			}
+			if expect.IsBBoxDefined() {
+				// exported bboxes are not kept
+				assert.Equal(t, expect.WKT(), o.WKT())
+				continue
+			}
+			assertSameBinary(t, js, o)
+		}
+	}
+}
+
+func TestWKBHex(t *testing.T) {
+	var tests = []struct{ hex, wkt string }{
+		{"0101000000000000000000f03f0000000000000040", `POINT(1 2)`},
+		{"00000000013ff00000000000004000000000000000", `POINT(1 2)`},
+		{"0101000020e6100000000000000000f03f0000000000000040", `POINT(1 2)`},
+		{"01e9030000000000000000f03f00000000000000400000000000000840", `POINT Z (1 2 3)`},
+		{"01010000a0e6100000000000000000f03f00000000000000400000000000000840", `POINT Z (1 2 3)`},
+		{"01d1070000000000000000f03f00000000000000400000000000000840", `POINT(1 2)`},
+		{"0102000000020000000000000000000000000000000000000000000000000000000000000000000040",
+			`LINESTRING(0 0,0 2)`},
+		{"010400000002000000010100000000000000000000000000000000000000" +
+			"0101000000000000000000f03f0000000000000040", `MULTIPOINT(0 0,1 2)`},
+		{"010700000000000000", `GEOMETRYCOLLECTION EMPTY`},
+		{"010300000000000000", `POLYGON EMPTY`},
+	}
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.hex)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		o, err := ParseWKB(data)
+		if assert.NoError(t, err, test.hex) {
+			assert.Equal(t, test.wkt, o.WKT(), test.hex)
+		}
+	}
+	assert.Equal(t, "0101000000000000000000f03f0000000000000040",
+		hex.EncodeToString(Make2DPoint(1, 2).WKB()))
+	assert.Equal(t, "00000003e93ff000000000000040000000000000004008000000000000",
+		hex.EncodeToString(Make3DPoint(1, 2, 3).AppendWKB(nil, binary.BigEndian)))
+	assert.Equal(t, "01010000a0e6100000000000000000f03f00000000000000400000000000000840",
+		hex.EncodeToString(Make3DPoint(1, 2, 3).AppendEWKB(nil, binary.LittleEndian, 4326)))
+	assert.Equal(t, "0101000000000000000000f03f0000000000000040",
+		hex.EncodeToString(Make2DPoint(1, 2).AppendEWKB(nil, binary.LittleEndian, 0)))
+}
+
+func TestWKBSimple(t *testing.T) {
+	for _, o := range []Object{Make2DRect(1, 2, 3, 4), Make3DRect(1, 2, 3, 4, 5, 6)} {
+		o2, err := ParseWKB(o.WKB())
+		assert.NoError(t, err)
+		assert.Equal(t, o.JSON(), o2.JSON())
+	}
+	assert.Nil(t, MakeString("hello").WKB())
+	o, err := ParseWKB(ParseJSON(`{"type":"LineString","coordinates":[[1,2,0],[3,4,0]]}`).WKB())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, o.Dims())
+}
+
+func TestWKBErrors(t *testing.T) {
+	for _, h := range []string{
+		"", "01", "0201000000", "0108000000", "01b90b0000",
+		"0101000000000000000000f03f",
+		"0101000000000000000000f03f000000000000004000",
+		"0101000000000000000000f87f000000000000f87f",
+		"010400000001000000010200000000000000",
+		"0104000000ffffffff",
+	} {
+		data, _ := hex.DecodeString(h)
+		_, err := ParseWKB(data)
+		assert.Error(t, err, h)
+	}
+}
+
+func TestWKBRandom(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 5000; i++ {
+		o := randTestObject(rnd)
+		var data []byte
+		switch rnd.Intn(3) {
+		case 0:
+			data = o.AppendWKB(nil, binary.LittleEndian)
+		case 1:
+			data = o.AppendWKB(nil, binary.BigEndian)
+		case 2:
+			data = o.AppendEWKB(nil, binary.BigEndian, 4326)
+		}
+		o2, err := ParseWKB(data)
+		if err != nil {
+			t.Fatalf("seed %d: %v\n%s", seed, err, o.WKT())
+		}
+		if o.WKT() != o2.WKT() {
+			t.Fatalf("seed %d: mismatch\nexpected: %s\n     got: %s", seed, o.WKT(), o2.WKT())
+		}
+		// corrupt and truncate, must not panic
+		data[rnd.Intn(len(data))] = byte(rnd.Int())
+		ParseWKB(data)
+		ParseWKB(data[:rnd.Intn(len(data))])
+	}
+}
@@ -0,0 +1,542 @@
+package geobin
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	errInvalidWKT    = errors.New("invalid wkt")
+	errEmptyWKTPoint = errors.New("invalid wkt, empty points are not supported")
+)
+
+// ParseWKT parses Well-Known Text and returns a geobin object. The POINT,
+// LINESTRING, POLYGON, MULTIPOINT, MULTILINESTRING, MULTIPOLYGON, and
+// GEOMETRYCOLLECTION types are supported, including the Z variants and EMPTY.
+// M values are dropped. An EWKT "SRID=n;" prefix is allowed and ignored.
+// Empty points cannot be represented and return an error.
+func ParseWKT(wkt string) (Object, error) {
+	r := wktReader{s: wkt}
+	r.ws()
+	if len(r.s)-r.i >= 5 && strings.EqualFold(r.s[r.i:r.i+5], "SRID=") {
+		semi := strings.IndexByte(r.s[r.i:], ';')
+		if semi == -1 {
+			return Object{}, errInvalidWKT
+		}
+		r.i += semi + 1
+	}
+	o, err := r.geometry()
+	if err != nil {
+		return Object{}, err
+	}
+	r.ws()
+	if r.i != len(r.s) {
+		return Object{}, errInvalidWKT
+	}
+	return o, nil
+}
+
+type wktReader struct {
+	s string
+	i int
+}
+
+// ws skips whitespace.
+func (r *wktReader) ws() {
+	for r.i < len(r.s) && r.s[r.i] <= ' ' {
+		r.i++
+	}
+}
+
+// word reads an uppercased keyword.
+func (r *wktReader) word() string {
+	r.ws()
+	start := r.i
+	for r.i < len(r.s) {
+		c := r.s[r.i]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			break
+		}
+		r.i++
+	}
+	return strings.ToUpper(r.s[start:r.i])
+}
+
+// peek returns the next non-whitespace byte, or zero at the end.
+func (r *wktReader) peek() byte {
+	r.ws()
+	if r.i == len(r.s) {
+		return 0
+	}
+	return r.s[r.i]
+}
+
+func (r *wktReader) expect(c byte) error {
+	if r.peek() != c {
+		return errInvalidWKT
+	}
+	r.i++
+	return nil
+}
+
+// empty reads the EMPTY keyword if it's next.
+func (r *wktReader) empty() bool {
+	save := r.i
+	if r.word() == "EMPTY" {
+		return true
+	}
+	r.i = save
+	return false
+}
+
+// wktDims is the layout of the positions for a geometry.
+type wktDims struct {
+	tagged bool // a Z, M, or ZM tag was provided
+	z, m   bool
+}
+
+func (r *wktReader) geometry() (Object, error) {
+	typ := r.word()
+	var d wktDims
+	switch typ {
+	case "POINTZ", "LINESTRINGZ", "POLYGONZ", "MULTIPOINTZ",
+		"MULTILINESTRINGZ", "MULTIPOLYGONZ", "GEOMETRYCOLLECTIONZ":
+		typ, d = typ[:len(typ)-1], wktDims{tagged: true, z: true}
+	}
+	if !d.tagged {
+		save := r.i
+		switch r.word() {
+		case "Z":
+			d = wktDims{tagged: true, z: true}
+		case "M":
+			d = wktDims{tagged: true, m: true}
+		case "ZM":
+			d = wktDims{tagged: true, z: true, m: true}
+		default:
+			r.i = save
+		}
+	}
+	switch typ {
+	default:
+		return Object{}, errInvalidWKT
+	case "POINT":
+		if r.empty() {
+			return Object{}, errEmptyWKTPoint
+		}
+		if err := r.expect('('); err != nil {
+			return Object{}, err
+		}
+		p, pd, err := r.position(d)
+		if err != nil {
+			return Object{}, err
+		}
+		if err := r.expect(')'); err != nil {
+			return Object{}, err
+		}
+		if pd.z {
+			return Make3DPoint(p[0], p[1], p[2]), nil
+		}
+		return Make2DPoint(p[0], p[1]), nil
+	case "MULTIPOINT", "LINESTRING":
+		w := newWKTVals(d)
+		vals, err := r.series(&w, typ == "MULTIPOINT")
+		if err != nil {
+			return Object{}, err
+		}
+		if typ == "MULTIPOINT" {
+			return level1Object(MultiPoint, gjson.Result{}, vals, w.dims, w.min, w.max), nil
+		}
+		return level1Object(LineString, gjson.Result{}, vals, w.dims, w.min, w.max), nil
+	case "MULTILINESTRING", "POLYGON":
+		w := newWKTVals(d)
+		vals, err := r.seriesList(&w)
+		if err != nil {
+			return Object{}, err
+		}
+		if typ == "POLYGON" {
+			return level2Object(Polygon, gjson.Result{}, vals, w.dims, w.min, w.max), nil
+		}
+		return level2Object(MultiLineString, gjson.Result{}, vals, w.dims, w.min, w.max), nil
+	case "MULTIPOLYGON":
+		w := newWKTVals(d)
+		var vals [][][][3]float64
+		if !r.empty() {
+			if err := r.expect('('); err != nil {
+				return Object{}, err
+			}
+			for {
+				tvals, err := r.seriesList(&w)
+				if err != nil {
+					return Object{}, err
+				}
+				vals = append(vals, tvals)
+				if r.peek() != ',' {
+					break
+				}
+				r.i++
+			}
+			if err := r.expect(')'); err != nil {
+				return Object{}, err
+			}
+		}
+		return level3Object(MultiPolygon, gjson.Result{}, vals, w.dims, w.min, w.max), nil
+	case "GEOMETRYCOLLECTION":
+		var geoms []Object
+		if !r.empty() {
+			if err := r.expect('('); err != nil {
+				return Object{}, err
+			}
+			for {
+				g, err := r.geometry()
+				if err != nil {
+					return Object{}, err
+				}
+				geoms = append(geoms, g)
+				if r.peek() != ',' {
+					break
+				}
+				r.i++
+			}
+			if err := r.expect(')'); err != nil {
+				return Object{}, err
+			}
+		}
+		return collectionObject(GeometryCollection, gjson.Result{}, geoms), nil
+	}
+}
+
+// wktVals tracks the dimensions and bounds of the values of a geometry.
+type wktVals struct {
+	d        wktDims
+	dims     int
+	min, max [3]float64
+}
+
+func newWKTVals(d wktDims) wktVals {
+	w := wktVals{d: d, min: baseMin, max: baseMax}
+	if d.z {
+		// keep a tagged Z, even when empty
+		w.dims = 3
+	}
+	return w
+}
+
+// position reads a single position and returns the values and the dimensions
+// of the position. Without a tag the number of values decides the
+// dimensions.
+func (r *wktReader) position(d wktDims) ([3]float64, wktDims, error) {
+	var vals [3]float64
+	var nums [4]float64
+	var n int
+	for n < 4 {
+		r.ws()
+		start := r.i
+		for r.i < len(r.s) {
+			c := r.s[r.i]
+			if c <= ' ' || c == ',' || c == ')' || c == '(' {
+				break
+			}
+			r.i++
+		}
+		if start == r.i {
+			break
+		}
+		f, err := strconv.ParseFloat(r.s[start:r.i], 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return vals, d, errInvalidWKT
+		}
+		nums[n] = f
+		n++
+	}
+	if !d.tagged {
+		// no tag, the number of values decides
+		switch n {
+		case 2:
+			d = wktDims{}
+		case 3:
+			d = wktDims{z: true}
+		case 4:
+			d = wktDims{z: true, m: true}
+		}
+	}
+	want := 2
+	if d.z {
+		want++
+	}
+	if d.m {
+		want++
+	}
+	if n != want {
+		return vals, d, errInvalidWKT
+	}
+	vals[0], vals[1] = nums[0], nums[1]
+	if d.z {
+		vals[2] = nums[2]
+	}
+	return vals, d, nil
+}
+
+// next reads a position and updates the dimensions and bounds. Without a
+// tag the first position decides the dimensions of the others.
+func (w *wktVals) next(r *wktReader) ([3]float64, error) {
+	vals, d, err := r.position(w.d)
+	if err != nil {
+		return vals, err
+	}
+	// the following positions must have the same dimensions
+	d.tagged = true
+	w.d = d
+	dims := 2
+	if d.z {
+		dims = 3
+	}
+	if dims > w.dims {
+		w.dims = dims
+	}
+	for i := 0; i < dims; i++ {
+		if vals[i] < w.min[i] {
+			w.min[i] = vals[i]
+		}
+		if vals[i] > w.max[i] {
+			w.max[i] = vals[i]
+		}
+	}
+	return vals, nil
+}
+
+// series reads a parenthesized list of positions, or EMPTY. When multipoint
+// is set each position may also be wrapped in parens.
+func (r *wktReader) series(w *wktVals, multipoint bool) ([][3]float64, error) {
+	if r.empty() {
+		return nil, nil
+	}
+	if err := r.expect('('); err != nil {
+		return nil, err
+	}
+	var vals [][3]float64
+	for {
+		wrapped := multipoint && r.peek() == '('
+		if wrapped {
+			r.i++
+		}
+		p, err := w.next(r)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, p)
+		if wrapped {
+			if err := r.expect(')'); err != nil {
+				return nil, err
+			}
+		}
+		if r.peek() != ',' {
+			break
+		}
+		r.i++
+	}
+	if err := r.expect(')'); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// seriesList reads a parenthesized list of series, or EMPTY.
+func (r *wktReader) seriesList(w *wktVals) ([][][3]float64, error) {
+	if r.empty() {
+		return nil, nil
+	}
+	if err := r.expect('('); err != nil {
+		return nil, err
+	}
+	var vals [][][3]float64
+	for {
+		tvals, err := r.series(w, false)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, tvals)
+		if r.peek() != ',' {
+			break
+		}
+		r.i++
+	}
+	if err := r.expect(')'); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// WKT returns the Well-Known Text representation of the object.
+func (o Object) WKT() string {
+	return string(o.AppendWKT(nil))
+}
+
+// AppendWKT appends the Well-Known Text representation of the object to the
+// provided input bytes and returns the modified slice. Features are written
+// as their geometry and FeatureCollections as a GEOMETRYCOLLECTION. Nothing
+// is appended for objects that are not valid geometries.
+func (o Object) AppendWKT(b []byte) []byte {
+	v, ok := o.view()
+	if !ok {
+		return b
+	}
+	return appendWKT(b, v)
+}
+
+func appendWKTTag(b []byte, tag string, dims int) []byte {
+	b = append(b, tag...)
+	if dims == 3 {
+		b = append(b, " Z "...)
+	}
+	return b
+}
+
+func appendWKTEmpty(b []byte) []byte {
+	if b[len(b)-1] != ' ' {
+		b = append(b, ' ')
+	}
+	return append(b, "EMPTY"...)
+}
+
+func appendWKTPosition(b []byte, p Position, dims int) []byte {
+	b = strconv.AppendFloat(b, p.X, 'f', -1, 64)
+	b = append(b, ' ')
+	b = strconv.AppendFloat(b, p.Y, 'f', -1, 64)
+	if dims == 3 {
+		b = append(b, ' ')
+		b = strconv.AppendFloat(b, p.Z, 'f', -1, 64)
+	}
+	return b
+}
+
+func appendWKTSeries(b []byte, s series) []byte {
+	if s.n == 0 {
+		return appendWKTEmpty(b)
+	}
+	b = append(b, '(')
+	for i := 0; i < s.n; i++ {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendWKTPosition(b, s.at(i), s.dims)
+	}
+	return append(b, ')')
+}
+
+func appendWKTSeriesList(b []byte, l seriesList) []byte {
+	if l.n == 0 {
+		return appendWKTEmpty(b)
+	}
+	b = append(b, '(')
+	first := true
+	l.forEach(func(s series) bool {
+		if !first {
+			b = append(b, ',')
+		}
+		first = false
+		b = appendWKTSeries(b, s)
+		return true
+	})
+	return append(b, ')')
+}
+
+func appendWKTPairs(b []byte, pairs [][]float64) []byte {
+	b = append(b, '(')
+	for i, pair := range pairs {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		for j, val := range pair {
+			if j > 0 {
+				b = append(b, ' ')
+			}
+			b = strconv.AppendFloat(b, val, 'f', -1, 64)
+		}
+	}
+	return append(b, ')')
+}
+
+func appendWKT(b []byte, v view) []byte {
+	if tail := v.obj.data[len(v.obj.data)-1]; tail>>3&1 == 0 && tail>>2&1 == 1 {
+		// simple rects are written the same as the geojson polygons
+		if v.dims == 2 {
+			b = append(b, "POLYGON("...)
+			b = appendWKTPairs(b, v.obj.simplePairsFor2DRect())
+			return append(b, ')')
+		}
+		b = append(b, "MULTIPOLYGON Z ("...)
+		for i, pairs := range v.obj.simplePairsFor3DRect() {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, '(')
+			b = appendWKTPairs(b, pairs)
+			b = append(b, ')')
+		}
+		return append(b, ')')
+	}
+	switch v.typ {
+	case Point:
+		b = appendWKTTag(b, "POINT", v.dims)
+		b = append(b, '(')
+		b = appendWKTPosition(b, v.point, v.dims)
+		return append(b, ')')
+	case MultiPoint, LineString:
+		if v.typ == MultiPoint {
+			b = appendWKTTag(b, "MULTIPOINT", v.dims)
+		} else {
+			b = appendWKTTag(b, "LINESTRING", v.dims)
+		}
+		s, _ := readSeries(v.geom, v.dims)
+		return appendWKTSeries(b, s)
+	case MultiLineString, Polygon:
+		if v.typ == Polygon {
+			b = appendWKTTag(b, "POLYGON", v.dims)
+		} else {
+			b = appendWKTTag(b, "MULTILINESTRING", v.dims)
+		}
+		l, _ := readSeriesList(v.geom, v.dims)
+		return appendWKTSeriesList(b, l)
+	case MultiPolygon:
+		b = appendWKTTag(b, "MULTIPOLYGON", v.dims)
+		n, _ := readUint32(v.geom)
+		if n == 0 {
+			return appendWKTEmpty(b)
+		}
+		b = append(b, '(')
+		first := true
+		forEachPolygon(v.geom, v.dims, func(rings seriesList) bool {
+			if !first {
+				b = append(b, ',')
+			}
+			first = false
+			b = appendWKTSeriesList(b, rings)
+			return true
+		})
+		return append(b, ')')
+	case Feature:
+		return appendWKT(b, v.child())
+	case GeometryCollection, FeatureCollection:
+		// the children carry their own dimensions
+		b = append(b, "GEOMETRYCOLLECTION"...)
+		n, data := v.children()
+		if n == 0 {
+			return appendWKTEmpty(b)
+		}
+		b = append(b, '(')
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			var c view
+			c, data = nextChild(data)
+			b = appendWKT(b, c)
+		}
+		return append(b, ')')
+	}
+	return b
+}
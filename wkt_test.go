@@ -0,0 +1,104 @@
+package geobin
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWKT(t *testing.T) {
+	var tests = []struct{ wkt, json string }{
+		{`POINT(1 2)`, `{"type":"Point","coordinates":[1,2]}`},
+		{`POINT Z (1 2 3)`, `{"type":"Point","coordinates":[1,2,3]}`},
+		{`MULTIPOINT(1 2,3.5 4)`, `{"type":"MultiPoint","coordinates":[[1,2],[3.5,4]]}`},
+		{`MULTIPOINT EMPTY`, `{"type":"MultiPoint","coordinates":[]}`},
+		{`LINESTRING Z (1 2 3,4 5 6)`, `{"type":"LineString","coordinates":[[1,2,3],[4,5,6]]}`},
+		{`LINESTRING EMPTY`, `{"type":"LineString","coordinates":[]}`},
+		{`MULTILINESTRING((1 2,3 4),(5 6,7 8))`,
+			`{"type":"MultiLineString","coordinates":[[[1,2],[3,4]],[[5,6],[7,8]]]}`},
+		{`POLYGON((0 0,0 6,12 -6,12 0,0 0),(1 1,1 2,2 2,2 1,1 1),(11 -1,11 -3,9 -1,11 -1))`, testPolyHoles},
+		{`POLYGON EMPTY`, `{"type":"Polygon","coordinates":[]}`},
+		{`MULTIPOLYGON(((0 0,0 1,1 1,0 0)),((5 5,5 6,6 6,5 5),(5.1 5.5,5.2 5.5,5.2 5.6,5.1 5.5)))`,
+			`{"type":"MultiPolygon","coordinates":[[[[0,0],[0,1],[1,1],[0,0]]],[[[5,5],[5,6],[6,6],[5,5]],[[5.1,5.5],[5.2,5.5],[5.2,5.6],[5.1,5.5]]]]}`},
+		{`MULTIPOLYGON EMPTY`, `{"type":"MultiPolygon","coordinates":[]}`},
+		{`GEOMETRYCOLLECTION(POINT(1 2),LINESTRING(1 2,3 4))`,
+			`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[1,2],[3,4]]}]}`},
+		{`GEOMETRYCOLLECTION EMPTY`, `{"type":"GeometryCollection","geometries":[]}`},
+	}
+	for _, test := range tests {
+		o, err := ParseWKT(test.wkt)
+		if !assert.NoError(t, err, test.wkt) {
+			continue
+		}
+		assertSameBinary(t, test.json, o)
+		assert.Equal(t, test.wkt, ParseJSON(test.json).WKT())
+	}
+}
+
+func TestWKTVariants(t *testing.T) {
+	var tests = []struct{ wkt, expect string }{
+		{` point ( 1  2 ) `, `POINT(1 2)`},
+		{`POINT (1 2 3)`, `POINT Z (1 2 3)`},
+		{`POINTZ(1 2 3)`, `POINT Z (1 2 3)`},
+		{`POINT M (1 2 3)`, `POINT(1 2)`},
+		{`POINT ZM (1 2 3 4)`, `POINT Z (1 2 3)`},
+		{`POINT (1 2 3 4)`, `POINT Z (1 2 3)`},
+		{`LINESTRING Z (1 2 0,3 4 0)`, `LINESTRING Z (1 2 0,3 4 0)`},
+		{`MULTIPOINT((1 2),(3 4))`, `MULTIPOINT(1 2,3 4)`},
+		{`SRID=4326;POINT(-112.5 33.25)`, `POINT(-112.5 33.25)`},
+		{`GEOMETRYCOLLECTION(POINT Z (1 2 3),POLYGON EMPTY)`,
+			`GEOMETRYCOLLECTION(POINT Z (1 2 3),POLYGON EMPTY)`},
+		{`POINT(1e3 -2.5E-1)`, `POINT(1000 -0.25)`},
+	}
+	for _, test := range tests {
+		o, err := ParseWKT(test.wkt)
+		if assert.NoError(t, err, test.wkt) {
+			assert.Equal(t, test.expect, o.WKT())
+		}
+	}
+}
+
+func TestWKTSimple(t *testing.T) {
+	assert.Equal(t, `POLYGON((1 2,1 4,3 4,3 2,1 2))`, Make2DRect(1, 2, 3, 4).WKT())
+	o, err := ParseWKT(Make3DRect(1, 2, 3, 4, 5, 6).WKT())
+	assert.NoError(t, err)
+	assert.Equal(t, Make3DRect(1, 2, 3, 4, 5, 6).JSON(), o.JSON())
+	assert.Equal(t, `POINT(1 2)`, Make2DPoint(1, 2).SetExData([]byte("extra")).WKT())
+	assert.Equal(t, `POINT(1 2)`, ParseJSON(`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}`).WKT())
+	assert.Equal(t, `GEOMETRYCOLLECTION(POINT(1 2))`, ParseJSON(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}]}`).WKT())
+	assert.Equal(t, ``, MakeString("hello").WKT())
+	assert.Equal(t, []byte("abc"), MakeString("hello").AppendWKT([]byte("abc")))
+}
+
+func TestWKTErrors(t *testing.T) {
+	for _, wkt := range []string{
+		``, `POINT`, `POINT EMPTY`, `POINT(1)`, `POINT(1 2`, `POINT(1 2) x`,
+		`POINT(1 a)`, `POINT Z (1 2)`, `POINT(1 2 3 4 5)`, `CIRCLE(1 2)`,
+		`LINESTRING(1 2,)`, `POLYGON(1 2,3 4)`, `MULTIPOLYGON((1 2))`,
+		`GEOMETRYCOLLECTION(POINT(1 2),)`, `SRID=4326 POINT(1 2)`,
+		`POINT(NaN 1)`, `POINT(1 Inf)`, `LINESTRING(1 2,3 -Inf)`,
+		`LINESTRING(1 2,3 4 5)`, `LINESTRING(1 2 3,4 5 6 7)`, `MULTIPOINT((1 2),(3 4 5))`,
+		`POLYGON((0 0,1 0,1 1,0 0),(0 0 1,1 0 1,1 1 1,0 0 1))`,
+	} {
+		_, err := ParseWKT(wkt)
+		assert.Error(t, err, wkt)
+	}
+}
+
+func TestWKTRandom(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 5000; i++ {
+		wkt := randTestObject(rnd).WKT()
+		o, err := ParseWKT(wkt)
+		if err != nil {
+			t.Fatalf("seed %d: %v\n%s", seed, err, wkt)
+		}
+		if o.WKT() != wkt {
+			t.Fatalf("seed %d: mismatch\nexpected: %s\n     got: %s", seed, wkt, o.WKT())
+		}
+	}
+}